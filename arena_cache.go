@@ -0,0 +1,172 @@
+package gorivets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+)
+
+// ArenaCache is a byte-oriented cache which keeps keys and values in one big
+// pre-allocated arena split into equal segments, which are used as a ring.
+// The arena and the index map (key hash -> arena offset) contain no pointers,
+// so the Go GC doesn't need to scan them, what makes the cache suitable for
+// holding millions of small blobs.
+//
+// When the ring comes back to an already used segment, all entries from the
+// segment are evicted, so the eviction order is the insertion order (FIFO)
+// rather than strict LRU. Keys with the same hash replace each other.
+//
+// Method calls should be guarded by synchronization primitives in
+// multi-thread environment.
+type ArenaCache struct {
+	arena   []byte
+	segSize int
+	segUsed []int
+	seg     int
+	index   map[uint64]uint64
+	size    int64
+}
+
+const cArenaHeaderSize = 16
+
+// NewArenaCache creates new ArenaCache with arena of maxSize bytes divided
+// into segments of segmentSize. An entry (key and value plus 16 bytes of a
+// header) should fit into one segment to be stored.
+func NewArenaCache(maxSize int64, segmentSize int) *ArenaCache {
+	if segmentSize <= cArenaHeaderSize || int64(segmentSize) > maxSize {
+		panic("ArenaCache segmentSize=" + strconv.Itoa(segmentSize) + " should be in (" +
+			strconv.Itoa(cArenaHeaderSize) + ".." + strconv.FormatInt(maxSize, 10) + "]")
+	}
+	segs := int(maxSize / int64(segmentSize))
+	ac := new(ArenaCache)
+	ac.arena = make([]byte, segs*segmentSize)
+	ac.segSize = segmentSize
+	ac.segUsed = make([]int, segs)
+	ac.index = make(map[uint64]uint64)
+	return ac
+}
+
+// Add puts the key-value pair into the cache, both slices are copied. Returns
+// false if the entry is too big to fit into a segment.
+func (ac *ArenaCache) Add(k, v []byte) bool {
+	es := cArenaHeaderSize + len(k) + len(v)
+	if es > ac.segSize {
+		return false
+	}
+	h := arenaHash(k)
+	ac.deleteByHash(h)
+
+	if ac.segUsed[ac.seg]+es > ac.segSize {
+		ac.seg = (ac.seg + 1) % len(ac.segUsed)
+		ac.evictSegment(ac.seg)
+	}
+	off := ac.seg*ac.segSize + ac.segUsed[ac.seg]
+	e := ac.arena[off : off+es]
+	binary.LittleEndian.PutUint32(e, uint32(len(k)))
+	binary.LittleEndian.PutUint32(e[4:], uint32(len(v)))
+	binary.LittleEndian.PutUint64(e[8:], h)
+	copy(e[cArenaHeaderSize:], k)
+	copy(e[cArenaHeaderSize+len(k):], v)
+
+	ac.segUsed[ac.seg] += es
+	ac.index[h] = uint64(off)
+	ac.size += int64(es)
+	return true
+}
+
+// Get returns a copy of the value stored for the key
+func (ac *ArenaCache) Get(k []byte) ([]byte, bool) {
+	e, ok := ac.entry(k)
+	if !ok {
+		return nil, false
+	}
+	kl := int(binary.LittleEndian.Uint32(e))
+	v := make([]byte, len(e)-cArenaHeaderSize-kl)
+	copy(v, e[cArenaHeaderSize+kl:])
+	return v, true
+}
+
+// Delete removes the key from the cache, returns whether the key was there
+func (ac *ArenaCache) Delete(k []byte) bool {
+	if _, ok := ac.entry(k); !ok {
+		return false
+	}
+	ac.deleteByHash(arenaHash(k))
+	return true
+}
+
+// Clear the cache
+func (ac *ArenaCache) Clear() {
+	for i := range ac.segUsed {
+		ac.segUsed[i] = 0
+	}
+	ac.seg = 0
+	ac.index = make(map[uint64]uint64)
+	ac.size = 0
+}
+
+func (ac *ArenaCache) Len() int {
+	return len(ac.index)
+}
+
+// Size returns number of arena bytes occupied by live entries including
+// their headers
+func (ac *ArenaCache) Size() int64 {
+	return ac.size
+}
+
+// entry returns arena slice for the key entry (header, key and value)
+func (ac *ArenaCache) entry(k []byte) ([]byte, bool) {
+	off, ok := ac.index[arenaHash(k)]
+	if !ok {
+		return nil, false
+	}
+	e := ac.entryAt(int(off))
+	kl := int(binary.LittleEndian.Uint32(e))
+	if !bytes.Equal(k, e[cArenaHeaderSize:cArenaHeaderSize+kl]) {
+		return nil, false
+	}
+	return e, true
+}
+
+func (ac *ArenaCache) entryAt(off int) []byte {
+	kl := int(binary.LittleEndian.Uint32(ac.arena[off:]))
+	vl := int(binary.LittleEndian.Uint32(ac.arena[off+4:]))
+	return ac.arena[off : off+cArenaHeaderSize+kl+vl]
+}
+
+func (ac *ArenaCache) deleteByHash(h uint64) {
+	off, ok := ac.index[h]
+	if !ok {
+		return
+	}
+	delete(ac.index, h)
+	ac.size -= int64(len(ac.entryAt(int(off))))
+}
+
+// evictSegment drops all live entries of the segment. Entries which were
+// deleted or replaced before are not referenced by the index anymore.
+func (ac *ArenaCache) evictSegment(seg int) {
+	start := seg * ac.segSize
+	end := start + ac.segUsed[seg]
+	for off := start; off < end; {
+		e := ac.entryAt(off)
+		h := binary.LittleEndian.Uint64(e[8:])
+		if o, ok := ac.index[h]; ok && int(o) == off {
+			delete(ac.index, h)
+			ac.size -= int64(len(e))
+		}
+		off += len(e)
+	}
+	ac.segUsed[seg] = 0
+}
+
+// arenaHash is FNV-1a 64 bits hash, calculated without allocations
+func arenaHash(k []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, b := range k {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	return h
+}
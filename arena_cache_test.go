@@ -0,0 +1,114 @@
+package gorivets
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestArenaCacheSimple(t *testing.T) {
+	ac := NewArenaCache(1000, 100)
+	if !ac.Add([]byte("a"), []byte("123")) || !ac.Add([]byte("b"), []byte("45")) {
+		t.Fatal("expecting the values to be added")
+	}
+	if ac.Len() != 2 {
+		t.Fatal("expecting len == 2, but len=" + strconv.Itoa(ac.Len()))
+	}
+	if ac.Size() != 2*cArenaHeaderSize+7 {
+		t.Fatal("unexpected size " + strconv.FormatInt(ac.Size(), 10))
+	}
+
+	v, ok := ac.Get([]byte("a"))
+	if !ok || string(v) != "123" {
+		t.Fatal("expecting \"123\", but got " + string(v))
+	}
+
+	ac.Add([]byte("a"), []byte("6"))
+	if v, _ := ac.Get([]byte("a")); string(v) != "6" || ac.Len() != 2 {
+		t.Fatal("expecting \"6\", but got " + string(v))
+	}
+	if ac.Size() != 2*cArenaHeaderSize+5 {
+		t.Fatal("unexpected size " + strconv.FormatInt(ac.Size(), 10))
+	}
+
+	if !ac.Delete([]byte("b")) || ac.Delete([]byte("b")) {
+		t.Fatal("expecting \"b\" to be deleted once")
+	}
+	if _, ok := ac.Get([]byte("b")); ok || ac.Len() != 1 || ac.Size() != cArenaHeaderSize+2 {
+		t.Fatal("expecting \"b\" is not in the cache")
+	}
+
+	if ac.Add([]byte("big"), make([]byte, 100)) {
+		t.Fatal("expecting the value is too big")
+	}
+
+	ac.Clear()
+	if ac.Len() != 0 || ac.Size() != 0 {
+		t.Fatal("expecting empty cache")
+	}
+
+	if CheckPanic(func() { NewArenaCache(100, 1000) }) == nil {
+		t.Fatal("expecting panic for wrong segment size")
+	}
+}
+
+func TestArenaCacheEviction(t *testing.T) {
+	// 3 segments, 2 entries of 50 bytes per segment
+	ac := NewArenaCache(300, 100)
+	val := make([]byte, 50-cArenaHeaderSize-1)
+	for i := 0; i < 6; i++ {
+		ac.Add([]byte{byte(i)}, val)
+	}
+	if ac.Len() != 6 || ac.Size() != 300 {
+		t.Fatal("expecting full cache, but len=" + strconv.Itoa(ac.Len()))
+	}
+
+	ac.Delete([]byte{1})
+	ac.Add([]byte{6}, val)
+	if ac.Len() != 5 || ac.Size() != 250 {
+		t.Fatal("expecting first segment to be evicted, but len=" + strconv.Itoa(ac.Len()))
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := ac.Get([]byte{byte(i)}); ok {
+			t.Fatal("expecting " + strconv.Itoa(i) + " to be evicted")
+		}
+	}
+	for i := 2; i < 7; i++ {
+		if _, ok := ac.Get([]byte{byte(i)}); !ok {
+			t.Fatal("expecting " + strconv.Itoa(i) + " in the cache")
+		}
+	}
+}
+
+const cGcBenchEntries = 1000000
+
+func BenchmarkArenaCacheGC(b *testing.B) {
+	ac := NewArenaCache(cGcBenchEntries*64, 1<<20)
+	val := make([]byte, 32)
+	for i := 0; i < cGcBenchEntries; i++ {
+		ac.Add([]byte(strconv.Itoa(i)), val)
+	}
+	benchmarkGC(b)
+	runtime.KeepAlive(ac)
+}
+
+func BenchmarkLRUGC(b *testing.B) {
+	l := NewLRU(cGcBenchEntries*64, nil)
+	for i := 0; i < cGcBenchEntries; i++ {
+		l.Add(strconv.Itoa(i), make([]byte, 32), 64)
+	}
+	benchmarkGC(b)
+	runtime.KeepAlive(l)
+}
+
+func benchmarkGC(b *testing.B) {
+	b.ResetTimer()
+	var pause time.Duration
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		runtime.GC()
+		pause += time.Since(start)
+	}
+	b.ReportMetric(float64(pause.Nanoseconds())/float64(b.N), "gc-ns/op")
+}
@@ -0,0 +1,382 @@
+package gorivets
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CacheRoute defines which requests are cached by CachingHandler and how
+	CacheRoute struct {
+		// Path prefix of the route, the longest matching prefix wins
+		Prefix string
+		// How long a response is served from the cache
+		Ttl time.Duration
+		// Query parameters which are included into the cache key, others
+		// are ignored
+		QueryParams []string
+		// Request headers which are included into the cache key
+		VaryHeaders []string
+		// Tags assigned to all responses of the route, see Purge()
+		Tags []string
+	}
+
+	// CachingHandler is http.Handler which caches complete responses (status,
+	// headers and body) of the wrapped handler in a sized LRU. Only GET
+	// requests matching one of the routes are cached, and only 200 responses
+	// without Cache-Control no-store or private are stored. The entry size
+	// is the body length.
+	//
+	// The cache key is built from the method, path, query parameters and
+	// request headers selected by the route. The cached responses can be
+	// invalidated by tags, which come from the route and from the CacheTagHeader
+	// set by the wrapped handler.
+	//
+	// If coalescing is on, only one of concurrent requests with the same key
+	// is passed to the wrapped handler when the response is not cached, others
+	// wait for its response.
+	//
+	// Responses of the cached routes are buffered until it is known that they
+	// cannot be cached: a status other than 200, no-store or private
+	// Cache-Control, a body bigger than the cache size, or a call of
+	// http.Flusher Flush(). After that the response is passed through to the
+	// client as is.
+	//
+	// Multithread: friendly
+	CachingHandler struct {
+		clock    ClockF
+		handler  http.Handler
+		routes   []CacheRoute
+		coalesce bool
+		maxSize  int64
+
+		lock     sync.Mutex
+		lru      LRU
+		tags     map[string]map[string]bool
+		inflight map[string]*handlerCall
+	}
+
+	handlerEntry struct {
+		status  int
+		header  http.Header
+		body    []byte
+		tags    []string
+		expires time.Time
+	}
+
+	handlerCall struct {
+		done  chan bool
+		entry *handlerEntry
+	}
+
+	// responseRecorder buffers the response of the wrapped handler, and passes
+	// it through to w when the response turns out not cacheable
+	responseRecorder struct {
+		w           http.ResponseWriter
+		header      http.Header
+		status      int
+		body        bytes.Buffer
+		maxSize     int64
+		wroteHeader bool
+		passThrough bool
+	}
+)
+
+const (
+	// Response header with comma separated tags, which the wrapped handler can
+	// set to the response in addition to the route tags
+	CacheTagHeader = "X-Cache-Tag"
+	// Response header which indicates whether the response is served from the
+	// cache: "HIT" or "MISS"
+	CacheStatusHeader = "X-Cache"
+)
+
+// NewCachingHandler wraps the handler into CachingHandler, which caches up to
+// maxSize bytes of responses for the routes provided.
+func NewCachingHandler(handler http.Handler, maxSize int64, coalesce bool, routes ...CacheRoute) *CachingHandler {
+	return NewCachingHandlerWithClock(handler, maxSize, coalesce, time.Now, routes...)
+}
+
+// NewCachingHandlerWithClock creates CachingHandler (see NewCachingHandler)
+// which takes the current time from the clock provided
+func NewCachingHandlerWithClock(handler http.Handler, maxSize int64, coalesce bool, clock ClockF, routes ...CacheRoute) *CachingHandler {
+	if clock == nil {
+		panic("clock should be provided.")
+	}
+	ch := &CachingHandler{handler: handler, coalesce: coalesce, maxSize: maxSize, clock: clock}
+	ch.routes = append(ch.routes, routes...)
+	// the longest prefix goes first
+	sort.SliceStable(ch.routes, func(i, j int) bool {
+		return len(ch.routes[i].Prefix) > len(ch.routes[j].Prefix)
+	})
+	ch.lru = NewLRU(maxSize, ch.onDelete)
+	ch.tags = make(map[string]map[string]bool)
+	ch.inflight = make(map[string]*handlerCall)
+	return ch
+}
+
+func (ch *CachingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt := ch.route(r)
+	if rt == nil || r.Method != "GET" {
+		ch.handler.ServeHTTP(w, r)
+		return
+	}
+
+	key := cacheKey(r, rt)
+	ch.lock.Lock()
+	if he := ch.get(key); he != nil {
+		ch.lock.Unlock()
+		he.write(w, "HIT")
+		return
+	}
+
+	if !ch.coalesce {
+		ch.lock.Unlock()
+		ch.serve(w, r, rt, key)
+		return
+	}
+
+	if call, ok := ch.inflight[key]; ok {
+		ch.lock.Unlock()
+		<-call.done
+		if call.entry == nil {
+			ch.handler.ServeHTTP(w, r)
+			return
+		}
+		call.entry.write(w, "HIT")
+		return
+	}
+	call := &handlerCall{done: make(chan bool)}
+	ch.inflight[key] = call
+	ch.lock.Unlock()
+
+	defer func() {
+		ch.lock.Lock()
+		delete(ch.inflight, key)
+		ch.lock.Unlock()
+		close(call.done)
+	}()
+	call.entry = ch.serve(w, r, rt, key)
+}
+
+// Purge removes all cached responses which have any of the tags provided.
+// Returns number of the removed responses.
+func (ch *CachingHandler) Purge(tags ...string) int {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	n := 0
+	for _, tag := range tags {
+		for key := range ch.tags[tag] {
+			if ch.lru.Delete(key) != nil {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// PurgeHandler returns http.Handler which purges the cached responses by the
+// "tag" query parameters, like "/purge?tag=users&tag=orders". Only POST and
+// DELETE methods are accepted. The number of removed responses is written
+// into the response body.
+func (ch *CachingHandler) PurgeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" && r.Method != "DELETE" {
+			w.Header().Set("Allow", "POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		tags := r.URL.Query()["tag"]
+		if len(tags) == 0 {
+			http.Error(w, "tag parameter expected", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(strconv.Itoa(ch.Purge(tags...))))
+	})
+}
+
+// serve calls the wrapped handler and caches the response if possible.
+// Returns the cached entry or nil.
+func (ch *CachingHandler) serve(w http.ResponseWriter, r *http.Request, rt *CacheRoute, key string) *handlerEntry {
+	rr := &responseRecorder{w: w, header: http.Header{}, status: http.StatusOK, maxSize: ch.maxSize}
+	ch.handler.ServeHTTP(rr, r)
+	if rr.passThrough {
+		return nil
+	}
+
+	he := &handlerEntry{status: rr.status, header: rr.header, body: rr.body.Bytes()}
+	cc := parseCacheControl(rr.header)
+	_, noStore := cc["no-store"]
+	_, private := cc["private"]
+	if he.status != http.StatusOK || noStore || private {
+		he.write(w, "MISS")
+		return nil
+	}
+
+	he.expires = ch.now().Add(rt.Ttl)
+	he.tags = append(he.tags, rt.Tags...)
+	for _, v := range rr.header[CacheTagHeader] {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				he.tags = append(he.tags, tag)
+			}
+		}
+	}
+
+	ch.lock.Lock()
+	ch.lru.Add(key, he, int64(len(he.body)))
+	if _, ok := ch.lru.Peek(key); ok {
+		for _, tag := range he.tags {
+			keys, ok := ch.tags[tag]
+			if !ok {
+				keys = make(map[string]bool)
+				ch.tags[tag] = keys
+			}
+			keys[key] = true
+		}
+	}
+	ch.lock.Unlock()
+
+	he.write(w, "MISS")
+	return he
+}
+
+// get returns not expired entry, must be called under the lock
+func (ch *CachingHandler) get(key string) *handlerEntry {
+	v, ok := ch.lru.Get(key)
+	if !ok {
+		return nil
+	}
+	he := v.(*handlerEntry)
+	if !ch.now().Before(he.expires) {
+		ch.lru.Delete(key)
+		return nil
+	}
+	return he
+}
+
+// onDelete is called by LRU under the lock, it removes the key from the tags
+// index
+func (ch *CachingHandler) onDelete(k, v interface{}) {
+	key := k.(string)
+	for _, tag := range v.(*handlerEntry).tags {
+		if keys, ok := ch.tags[tag]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(ch.tags, tag)
+			}
+		}
+	}
+}
+
+func (ch *CachingHandler) route(r *http.Request) *CacheRoute {
+	for i := range ch.routes {
+		if strings.HasPrefix(r.URL.Path, ch.routes[i].Prefix) {
+			return &ch.routes[i]
+		}
+	}
+	return nil
+}
+
+func (ch *CachingHandler) now() time.Time {
+	return ch.clock()
+}
+
+func cacheKey(r *http.Request, rt *CacheRoute) string {
+	var buf bytes.Buffer
+	buf.WriteString(r.Method)
+	buf.WriteByte(' ')
+	buf.WriteString(r.URL.Path)
+	q := r.URL.Query()
+	for _, p := range rt.QueryParams {
+		buf.WriteString("\n?")
+		buf.WriteString(p)
+		buf.WriteByte('=')
+		buf.WriteString(strings.Join(q[p], "&"))
+	}
+	for _, h := range rt.VaryHeaders {
+		buf.WriteString("\n")
+		buf.WriteString(h)
+		buf.WriteByte(':')
+		buf.WriteString(strings.Join(r.Header[http.CanonicalHeaderKey(h)], ","))
+	}
+	return buf.String()
+}
+
+func (he *handlerEntry) write(w http.ResponseWriter, cacheStatus string) {
+	h := w.Header()
+	for k, v := range he.header {
+		h[k] = v
+	}
+	h.Set(CacheStatusHeader, cacheStatus)
+	w.WriteHeader(he.status)
+	w.Write(he.body)
+}
+
+func (rr *responseRecorder) Header() http.Header {
+	if rr.passThrough {
+		return rr.w.Header()
+	}
+	return rr.header
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.wroteHeader {
+		return
+	}
+	rr.wroteHeader = true
+	rr.status = status
+	cc := parseCacheControl(rr.header)
+	_, noStore := cc["no-store"]
+	_, private := cc["private"]
+	if status != http.StatusOK || noStore || private {
+		rr.startPassThrough()
+	}
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if !rr.wroteHeader {
+		rr.WriteHeader(http.StatusOK)
+	}
+	if !rr.passThrough && int64(rr.body.Len()+len(b)) > rr.maxSize {
+		rr.startPassThrough()
+	}
+	if rr.passThrough {
+		return rr.w.Write(b)
+	}
+	return rr.body.Write(b)
+}
+
+// Flush passes the response through, streamed responses are not cached
+func (rr *responseRecorder) Flush() {
+	if !rr.wroteHeader {
+		rr.WriteHeader(http.StatusOK)
+	}
+	rr.startPassThrough()
+	if f, ok := rr.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rr *responseRecorder) startPassThrough() {
+	if rr.passThrough {
+		return
+	}
+	rr.passThrough = true
+	h := rr.w.Header()
+	for k, v := range rr.header {
+		h[k] = v
+	}
+	h.Set(CacheStatusHeader, "MISS")
+	rr.w.WriteHeader(rr.status)
+	if rr.body.Len() > 0 {
+		rr.w.Write(rr.body.Bytes())
+		rr.body.Reset()
+	}
+}
@@ -0,0 +1,203 @@
+package gorivets
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func cacheHandlerGet(h http.Handler, url string, hdrs ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", url, nil)
+	for i := 0; i+1 < len(hdrs); i += 2 {
+		req.Header.Set(hdrs[i], hdrs[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCachingHandlerKey(t *testing.T) {
+	var calls int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-N", strconv.Itoa(int(n)))
+		io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery)
+	})
+	now := time.Now()
+	ch := NewCachingHandlerWithClock(h, 1000, false, func() time.Time { return now },
+		CacheRoute{Prefix: "/", Ttl: time.Minute},
+		CacheRoute{Prefix: "/users", Ttl: time.Second, QueryParams: []string{"id"}, VaryHeaders: []string{"Accept"}})
+
+	rr := cacheHandlerGet(ch, "/users?id=1&x=1")
+	if rr.Header().Get(CacheStatusHeader) != "MISS" || rr.Body.String() != "/users?id=1&x=1" {
+		t.Fatal("expecting miss, but got " + rr.Header().Get(CacheStatusHeader))
+	}
+	rr = cacheHandlerGet(ch, "/users?x=2&id=1")
+	if rr.Header().Get(CacheStatusHeader) != "HIT" || rr.Body.String() != "/users?id=1&x=1" ||
+		rr.Header().Get("X-N") != "1" {
+		t.Fatal("expecting hit, not selected parameters are ignored")
+	}
+	cacheHandlerGet(ch, "/users?id=2")
+	cacheHandlerGet(ch, "/users?id=1", "Accept", "text/plain")
+	if calls != 3 {
+		t.Fatal("expecting different id and Accept to be misses, calls=" + strconv.Itoa(int(calls)))
+	}
+
+	now = now.Add(2 * time.Second)
+	cacheHandlerGet(ch, "/users?id=1")
+	cacheHandlerGet(ch, "/other")
+	cacheHandlerGet(ch, "/other")
+	if calls != 5 {
+		t.Fatal("expecting /users entry expired, but /other is cached, calls=" + strconv.Itoa(int(calls)))
+	}
+
+	req := httptest.NewRequest("POST", "/other", nil)
+	ch.ServeHTTP(httptest.NewRecorder(), req)
+	if calls != 6 {
+		t.Fatal("expecting POST is not cached")
+	}
+}
+
+func TestCachingHandlerNilClock(t *testing.T) {
+	if CheckPanic(func() { NewCachingHandlerWithClock(http.NotFoundHandler(), 1000, false, nil) }) == nil {
+		t.Fatal("expecting panic when the clock is not provided")
+	}
+}
+
+func TestCachingHandlerNotCacheable(t *testing.T) {
+	var calls int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ch := NewCachingHandler(h, 1000, false, CacheRoute{Prefix: "/", Ttl: time.Minute})
+	cacheHandlerGet(ch, "/private")
+	cacheHandlerGet(ch, "/private")
+	rr := cacheHandlerGet(ch, "/missing")
+	cacheHandlerGet(ch, "/missing")
+	if calls != 4 || rr.Code != http.StatusNotFound || ch.lru.Len() != 0 {
+		t.Fatal("expecting nothing is cached, calls=" + strconv.Itoa(int(calls)))
+	}
+}
+
+func TestCachingHandlerPurge(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" {
+			w.Header().Set(CacheTagHeader, "a, common")
+		}
+		io.WriteString(w, "body")
+	})
+	ch := NewCachingHandler(h, 1000, false, CacheRoute{Prefix: "/", Ttl: time.Minute, Tags: []string{"all"}})
+	cacheHandlerGet(ch, "/a")
+	cacheHandlerGet(ch, "/b")
+	if ch.lru.Len() != 2 {
+		t.Fatal("expecting 2 cached responses")
+	}
+
+	if ch.Purge("a") != 1 || ch.lru.Len() != 1 || len(ch.tags["common"]) != 0 {
+		t.Fatal("expecting /a to be purged")
+	}
+
+	ph := ch.PurgeHandler()
+	rr := cacheHandlerGet(ph, "/purge?tag=all")
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatal("expecting GET is not allowed")
+	}
+	rr = httptest.NewRecorder()
+	ph.ServeHTTP(rr, httptest.NewRequest("POST", "/purge?tag=all&tag=a", nil))
+	if rr.Body.String() != "1" || ch.lru.Len() != 0 || len(ch.tags) != 0 {
+		t.Fatal("expecting /b to be purged, but got " + rr.Body.String())
+	}
+}
+
+func TestCachingHandlerCoalesce(t *testing.T) {
+	var calls int32
+	release := make(chan bool)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		io.WriteString(w, "body")
+	})
+	ch := NewCachingHandler(h, 1000, true, CacheRoute{Prefix: "/", Ttl: time.Minute})
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = cacheHandlerGet(ch, "/a").Body.String()
+		}(i)
+	}
+	for {
+		ch.lock.Lock()
+		_, ok := ch.inflight["GET /a"]
+		ch.lock.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatal("expecting 1 call of the handler, but calls=" + strconv.Itoa(int(calls)))
+	}
+	for _, b := range bodies {
+		if b != "body" {
+			t.Fatal("expecting the same body for all requests, but got " + b)
+		}
+	}
+}
+
+func TestCachingHandlerPassThrough(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big":
+			io.WriteString(w, strings.Repeat("x", 600))
+			io.WriteString(w, strings.Repeat("y", 600))
+		case "/stream":
+			io.WriteString(w, "part1")
+			w.(http.Flusher).Flush()
+			io.WriteString(w, "part2")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "not found")
+		default:
+			io.WriteString(w, "small")
+		}
+	})
+	ch := NewCachingHandler(h, 1000, false, CacheRoute{Prefix: "/", Ttl: time.Minute})
+	for _, p := range []string{"/a", "/b", "/c"} {
+		cacheHandlerGet(ch, p)
+	}
+
+	rr := cacheHandlerGet(ch, "/big")
+	if rr.Body.Len() != 1200 || rr.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Fatal("expecting complete oversized body, but length=" + strconv.Itoa(rr.Body.Len()))
+	}
+	rr = cacheHandlerGet(ch, "/stream")
+	if rr.Body.String() != "part1part2" || !rr.Flushed {
+		t.Fatal("expecting the flushed response is passed through, but got " + rr.Body.String())
+	}
+	rr = cacheHandlerGet(ch, "/missing")
+	if rr.Code != http.StatusNotFound || rr.Body.String() != "not found" {
+		t.Fatal("expecting 404 is passed through")
+	}
+	if ch.lru.Len() != 3 {
+		t.Fatal("expecting small responses stay cached, but len=" + strconv.Itoa(ch.lru.Len()))
+	}
+}
@@ -0,0 +1,292 @@
+package gorivets
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachingTransport is http.RoundTripper which caches responses for GET
+// requests in a time-based LRU. The entry size is the response body length.
+//
+// Freshness of a response is defined by Cache-Control max-age or Expires
+// headers. Responses with Cache-Control no-store are not cached, responses
+// with no-cache are cached but revalidated every time. Stale responses are
+// revalidated with If-None-Match or If-Modified-Since, if the response had
+// ETag or Last-Modified headers. Responses served from the cache have
+// "X-From-Cache: 1" header. Responses with bodies bigger than the cache size
+// are passed through without buffering them.
+//
+// Multithread: friendly
+type CachingTransport struct {
+	// The transport used to make requests, http.DefaultTransport if nil
+	Transport http.RoundTripper
+
+	clock   ClockF
+	maxSize int64
+	lock    sync.Mutex
+	lru     LRU
+}
+
+const cFromCacheHeader = "X-From-Cache"
+
+type cachedResponse struct {
+	status     string
+	statusCode int
+	header     http.Header
+	body       []byte
+	// vary contains values of the request headers listed in Vary
+	vary       http.Header
+	fresh      time.Time
+	revalidate bool
+}
+
+// prefixedBody returns the already read prefix of the body and then the rest
+// of it
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// NewCachingTransport creates new CachingTransport over transport (can be nil)
+// which caches up to maxSize bytes of responses bodies. A cached entry, which
+// is not used for maxIdle, is dropped even though it can be still fresh.
+func NewCachingTransport(transport http.RoundTripper, maxSize int64, maxIdle time.Duration) *CachingTransport {
+	return NewCachingTransportWithClock(transport, maxSize, maxIdle, time.Now)
+}
+
+// NewCachingTransportWithClock creates CachingTransport (see
+// NewCachingTransport) which takes the current time from the clock provided
+func NewCachingTransportWithClock(transport http.RoundTripper, maxSize int64, maxIdle time.Duration, clock ClockF) *CachingTransport {
+	return &CachingTransport{Transport: transport, clock: clock, maxSize: maxSize,
+		lru: NewTtlLRUWithClock(maxSize, maxIdle, nil, clock)}
+}
+
+func (ct *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	if req.Method != "GET" && req.Method != "HEAD" {
+		// unsafe methods invalidate the cached resource
+		ct.delete(key)
+		return ct.transport().RoundTrip(req)
+	}
+	if req.Method != "GET" || req.Header.Get("Range") != "" {
+		return ct.transport().RoundTrip(req)
+	}
+
+	reqCc := parseCacheControl(req.Header)
+	if _, ok := reqCc["no-store"]; ok {
+		ct.delete(key)
+		return ct.transport().RoundTrip(req)
+	}
+
+	cr := ct.get(key, req)
+	if cr != nil {
+		_, noCache := reqCc["no-cache"]
+		if !noCache && !cr.revalidate && ct.now().Before(cr.fresh) {
+			return cr.response(req), nil
+		}
+		if cr.header.Get("ETag") == "" && cr.header.Get("Last-Modified") == "" {
+			cr = nil
+		}
+	}
+
+	if cr != nil {
+		req = revalidationRequest(req, cr)
+	}
+	resp, err := ct.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cr != nil && resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		cr = cr.updated(resp.Header, ct.now())
+		ct.put(key, cr)
+		return cr.response(req), nil
+	}
+
+	return ct.store(key, req, resp)
+}
+
+// store caches the response if it is cacheable. The response body is read
+// completely in the case, but no more than the cache size.
+func (ct *CachingTransport) store(key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	cc := parseCacheControl(resp.Header)
+	_, noStore := cc["no-store"]
+	if resp.StatusCode != http.StatusOK || noStore || resp.Header.Get("Vary") == "*" ||
+		resp.ContentLength > ct.maxSize {
+		ct.delete(key)
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, ct.maxSize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > ct.maxSize {
+		ct.delete(key)
+		resp.Body = prefixedBody{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	cr := &cachedResponse{status: resp.Status, statusCode: resp.StatusCode, body: body, vary: http.Header{}}
+	for _, h := range varyHeaders(resp.Header) {
+		cr.vary[h] = req.Header[h]
+	}
+	cr = cr.updated(resp.Header, ct.now())
+	if cr.revalidate || cr.fresh.After(ct.now()) || cr.header.Get("ETag") != "" ||
+		cr.header.Get("Last-Modified") != "" {
+		ct.put(key, cr)
+	} else {
+		ct.delete(key)
+	}
+	return resp, nil
+}
+
+func (ct *CachingTransport) get(key string, req *http.Request) *cachedResponse {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	v, ok := ct.lru.Get(key)
+	if !ok {
+		return nil
+	}
+	cr := v.(*cachedResponse)
+	for h, vals := range cr.vary {
+		if strings.Join(vals, ",") != strings.Join(req.Header[h], ",") {
+			return nil
+		}
+	}
+	return cr
+}
+
+func (ct *CachingTransport) put(key string, cr *cachedResponse) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	ct.lru.Add(key, cr, int64(len(cr.body)))
+}
+
+func (ct *CachingTransport) delete(key string) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	ct.lru.DeleteWithCallback(key, false)
+}
+
+func (ct *CachingTransport) transport() http.RoundTripper {
+	if ct.Transport != nil {
+		return ct.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (ct *CachingTransport) now() time.Time {
+	return ct.clock()
+}
+
+// updated returns copy of the cached response with headers merged and the
+// freshness recalculated
+func (cr *cachedResponse) updated(header http.Header, now time.Time) *cachedResponse {
+	res := *cr
+	res.header = http.Header{}
+	for h, vals := range cr.header {
+		res.header[h] = vals
+	}
+	for h, vals := range header {
+		res.header[h] = vals
+	}
+
+	cc := parseCacheControl(res.header)
+	_, res.revalidate = cc["no-cache"]
+	res.fresh = time.Time{}
+	if ma, ok := cc["max-age"]; ok {
+		if sec, err := strconv.ParseInt(ma, 10, 64); err == nil {
+			res.fresh = now.Add(time.Duration(sec) * time.Second)
+		}
+	} else if exp := res.header.Get("Expires"); exp != "" {
+		if et, err := http.ParseTime(exp); err == nil {
+			// Expires is relative to the server Date
+			if d, err := http.ParseTime(res.header.Get("Date")); err == nil {
+				res.fresh = now.Add(et.Sub(d))
+			} else {
+				res.fresh = et
+			}
+		}
+	}
+	return &res
+}
+
+func (cr *cachedResponse) response(req *http.Request) *http.Response {
+	header := http.Header{}
+	for h, vals := range cr.header {
+		header[h] = vals
+	}
+	header.Set(cFromCacheHeader, "1")
+	return &http.Response{
+		Status:        cr.status,
+		StatusCode:    cr.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cr.body)),
+		ContentLength: int64(len(cr.body)),
+		Request:       req,
+	}
+}
+
+func revalidationRequest(req *http.Request, cr *cachedResponse) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	r.Header = http.Header{}
+	for h, vals := range req.Header {
+		r.Header[h] = vals
+	}
+	if etag := cr.header.Get("ETag"); etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+	if lm := cr.header.Get("Last-Modified"); lm != "" {
+		r.Header.Set("If-Modified-Since", lm)
+	}
+	return r
+}
+
+// parseCacheControl returns Cache-Control directives with their values
+func parseCacheControl(header http.Header) map[string]string {
+	res := make(map[string]string)
+	for _, v := range header["Cache-Control"] {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			name, val := d, ""
+			if idx := strings.Index(d, "="); idx >= 0 {
+				name, val = d[:idx], strings.Trim(d[idx+1:], "\" ")
+			}
+			res[strings.ToLower(strings.TrimSpace(name))] = val
+		}
+	}
+	return res
+}
+
+func varyHeaders(header http.Header) []string {
+	var res []string
+	for _, v := range header["Vary"] {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				res = append(res, http.CanonicalHeaderKey(h))
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,230 @@
+package gorivets
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type cacheTestServer struct {
+	*httptest.Server
+	hits    int32
+	handler func(w http.ResponseWriter, r *http.Request)
+}
+
+func newCacheTestServer(handler func(w http.ResponseWriter, r *http.Request)) *cacheTestServer {
+	ts := &cacheTestServer{handler: handler}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ts.hits, 1)
+		ts.handler(w, r)
+	}))
+	return ts
+}
+
+func (ts *cacheTestServer) requests() int {
+	return int(atomic.LoadInt32(&ts.hits))
+}
+
+func cacheTestGet(t *testing.T, c *http.Client, url string, hdrs ...string) (*http.Response, string) {
+	req, _ := http.NewRequest("GET", url, nil)
+	for i := 0; i+1 < len(hdrs); i += 2 {
+		req.Header.Set(hdrs[i], hdrs[i+1])
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	return resp, string(body)
+}
+
+func TestCachingTransportMaxAge(t *testing.T) {
+	ts := newCacheTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		io.WriteString(w, "hello")
+	})
+	defer ts.Close()
+
+	now := time.Now()
+	ct := NewCachingTransportWithClock(nil, 1000, time.Hour, func() time.Time { return now })
+	c := &http.Client{Transport: ct}
+
+	resp, body := cacheTestGet(t, c, ts.URL+"/a")
+	if body != "hello" || resp.Header.Get(cFromCacheHeader) != "" {
+		t.Fatal("expecting response from the server")
+	}
+	resp, body = cacheTestGet(t, c, ts.URL+"/a")
+	if body != "hello" || resp.Header.Get(cFromCacheHeader) != "1" || ts.requests() != 1 {
+		t.Fatal("expecting response from the cache")
+	}
+	if ct.lru.Size() != 5 {
+		t.Fatal("expecting entry size is the body length, but size=" + strconv.FormatInt(ct.lru.Size(), 10))
+	}
+
+	cacheTestGet(t, c, ts.URL+"/a", "Cache-Control", "no-cache")
+	if ts.requests() != 2 {
+		t.Fatal("expecting request no-cache goes to the server")
+	}
+
+	now = now.Add(61 * time.Second)
+	cacheTestGet(t, c, ts.URL+"/a")
+	if ts.requests() != 3 {
+		t.Fatal("expecting stale response without validators to be re-requested")
+	}
+
+	req, _ := http.NewRequest("POST", ts.URL+"/a", nil)
+	resp, _ = c.Do(req)
+	resp.Body.Close()
+	if ct.lru.Len() != 0 {
+		t.Fatal("expecting POST invalidates the cached resource")
+	}
+}
+
+func TestCachingTransportNoStore(t *testing.T) {
+	ts := newCacheTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store, max-age=60")
+		io.WriteString(w, "hello")
+	})
+	defer ts.Close()
+
+	c := &http.Client{Transport: NewCachingTransport(nil, 1000, time.Hour)}
+	cacheTestGet(t, c, ts.URL)
+	cacheTestGet(t, c, ts.URL)
+	if ts.requests() != 2 {
+		t.Fatal("expecting no-store response is not cached")
+	}
+}
+
+func TestCachingTransportExpires(t *testing.T) {
+	now := time.Now()
+	ts := newCacheTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		w.Header().Set("Expires", now.Add(time.Minute).UTC().Format(http.TimeFormat))
+		io.WriteString(w, "hello")
+	})
+	defer ts.Close()
+
+	ct := NewCachingTransportWithClock(nil, 1000, time.Hour, func() time.Time { return now })
+	c := &http.Client{Transport: ct}
+	cacheTestGet(t, c, ts.URL)
+	now = now.Add(59 * time.Second)
+	cacheTestGet(t, c, ts.URL)
+	if ts.requests() != 1 {
+		t.Fatal("expecting the response is fresh")
+	}
+	now = now.Add(2 * time.Second)
+	cacheTestGet(t, c, ts.URL)
+	if ts.requests() != 2 {
+		t.Fatal("expecting the response is expired")
+	}
+}
+
+func TestCachingTransportRevalidate(t *testing.T) {
+	lm := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	ts := newCacheTestServer(nil)
+	ts.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", "\"v1\"")
+		w.Header().Set("Last-Modified", lm)
+		if r.Header.Get("If-None-Match") == "\"v1\"" && r.Header.Get("If-Modified-Since") == lm {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, "hello")
+	}
+	defer ts.Close()
+
+	c := &http.Client{Transport: NewCachingTransport(nil, 1000, time.Hour)}
+	cacheTestGet(t, c, ts.URL)
+	resp, body := cacheTestGet(t, c, ts.URL)
+	if ts.requests() != 2 || body != "hello" || resp.StatusCode != http.StatusOK ||
+		resp.Header.Get(cFromCacheHeader) != "1" {
+		t.Fatal("expecting revalidated response from the cache")
+	}
+
+	ts.handler = func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "world")
+	}
+	_, body = cacheTestGet(t, c, ts.URL)
+	if body != "world" {
+		t.Fatal("expecting new response, but got " + body)
+	}
+}
+
+func TestCachingTransportVary(t *testing.T) {
+	ts := newCacheTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		io.WriteString(w, r.Header.Get("Accept-Language"))
+	})
+	defer ts.Close()
+
+	c := &http.Client{Transport: NewCachingTransport(nil, 1000, time.Hour)}
+	cacheTestGet(t, c, ts.URL, "Accept-Language", "en")
+	_, body := cacheTestGet(t, c, ts.URL, "Accept-Language", "de")
+	if body != "de" || ts.requests() != 2 {
+		t.Fatal("expecting different Accept-Language to be a miss")
+	}
+	cacheTestGet(t, c, ts.URL, "Accept-Language", "de")
+	if ts.requests() != 2 {
+		t.Fatal("expecting the same Accept-Language to be a hit")
+	}
+}
+
+func TestCachingTransportOversized(t *testing.T) {
+	big := strings.Repeat("x", 2000)
+	ts := newCacheTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.URL.Path == "/chunked" {
+			// no Content-Length
+			io.WriteString(w, big[:1000])
+			w.(http.Flusher).Flush()
+			io.WriteString(w, big[1000:])
+			return
+		}
+		if r.URL.Path == "/big" {
+			io.WriteString(w, big)
+			return
+		}
+		io.WriteString(w, "hello")
+	})
+	defer ts.Close()
+
+	ct := NewCachingTransport(nil, 1000, time.Hour)
+	c := &http.Client{Transport: ct}
+	cacheTestGet(t, c, ts.URL+"/a")
+	cacheTestGet(t, c, ts.URL+"/b")
+	for _, path := range []string{"/big", "/chunked"} {
+		_, body := cacheTestGet(t, c, ts.URL+path)
+		if body != big {
+			t.Fatal("expecting complete body for " + path + ", but length=" + strconv.Itoa(len(body)))
+		}
+	}
+	if ct.lru.Len() != 2 {
+		t.Fatal("expecting oversized responses are not cached, but len=" + strconv.Itoa(ct.lru.Len()))
+	}
+}
+
+func TestCachingTransportIdleClock(t *testing.T) {
+	ts := newCacheTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=3600")
+		io.WriteString(w, "hello")
+	})
+	defer ts.Close()
+
+	now := time.Now()
+	ct := NewCachingTransportWithClock(nil, 1000, time.Minute, func() time.Time { return now })
+	c := &http.Client{Transport: ct}
+	cacheTestGet(t, c, ts.URL+"/a")
+	now = now.Add(2 * time.Minute)
+	cacheTestGet(t, c, ts.URL+"/b")
+	if _, ok := ct.lru.Peek(ts.URL + "/a"); ok {
+		t.Fatal("expecting idle entry is dropped by the transport clock")
+	}
+}
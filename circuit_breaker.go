@@ -0,0 +1,342 @@
+package gorivets
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	BreakerState int
+
+	// BreakerCallback is notified about circuit breaker state changes, k is
+	// the key of the breaker in BreakerRegistry (nil for standalone ones)
+	BreakerCallback func(k interface{}, from, to BreakerState)
+
+	// BreakerTicket is returned by CircuitBreaker.Allow() and identifies the
+	// breaker state generation the request was allowed in. A result reported
+	// with a ticket of an older generation is ignored.
+	BreakerTicket int64
+
+	BreakerConfig struct {
+		// The rolling window length where the requests results are counted
+		Window time.Duration
+		// Number of buckets the window is divided to, 10 if not specified
+		Buckets int
+		// Minimum number of requests in the window before the failure ratio
+		// is considered, 1 if not specified
+		MinRequests int
+		// The breaker opens when failures/requests in the window reaches the
+		// ratio
+		FailureRatio float64
+		// How long the breaker stays open before letting probe requests in
+		Cooldown time.Duration
+		// Number of probe requests in half-open state, all of them should
+		// succeed to close the breaker. 1 if not specified
+		HalfOpenRequests int
+	}
+
+	// CircuitBreaker stops passing requests to a failing downstream. In closed
+	// state all requests are allowed and their results are counted in the
+	// rolling window. When the failure ratio is reached, the breaker opens and
+	// rejects requests for the cooldown period. After that it becomes
+	// half-open and lets HalfOpenRequests probes through: the breaker closes
+	// if all of them succeed, and opens again on the first failure. The
+	// probes, which results are not reported during the cooldown period, are
+	// considered lost, and new probes are let through then.
+	//
+	// Multithread: friendly
+	CircuitBreaker struct {
+		lock     sync.Mutex
+		cfg      BreakerConfig
+		clock    ClockF
+		key      interface{}
+		callback BreakerCallback
+		state    BreakerState
+		openedAt time.Time
+		probesAt time.Time
+		bucketSz time.Duration
+		buckets  []breakerBucket
+		probes   int
+		probesOk int
+		// incremented on every state change and lost probes reset
+		gen BreakerTicket
+	}
+
+	breakerBucket struct {
+		epoch    int64
+		requests int
+		failures int
+	}
+
+	// BreakerRegistry keeps circuit breakers by key (downstream endpoint for
+	// instance) in time-based LRU, so breakers of endpoints, which are not
+	// used anymore, are dropped.
+	//
+	// Multithread: friendly
+	BreakerRegistry struct {
+		lock     sync.Mutex
+		cfg      BreakerConfig
+		clock    ClockF
+		callback BreakerCallback
+		lru      LRU
+	}
+)
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+// ErrBreakerOpen is returned when a request is rejected by a circuit breaker
+var ErrBreakerOpen = errors.New("Circuit breaker is open")
+
+func (bs BreakerState) String() string {
+	switch bs {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "BreakerState(" + strconv.Itoa(int(bs)) + ")"
+}
+
+// NewCircuitBreaker creates new closed CircuitBreaker, the callback can be nil
+func NewCircuitBreaker(cfg BreakerConfig, callback BreakerCallback) *CircuitBreaker {
+	return NewCircuitBreakerWithClock(cfg, callback, time.Now)
+}
+
+// NewCircuitBreakerWithClock creates CircuitBreaker (see NewCircuitBreaker)
+// which takes the current time from the clock provided
+func NewCircuitBreakerWithClock(cfg BreakerConfig, callback BreakerCallback, clock ClockF) *CircuitBreaker {
+	if clock == nil {
+		panic("clock should be provided.")
+	}
+	return newCircuitBreaker(nil, checkBreakerConfig(cfg), callback, clock)
+}
+
+func newCircuitBreaker(k interface{}, cfg BreakerConfig, callback BreakerCallback, clock ClockF) *CircuitBreaker {
+	return &CircuitBreaker{key: k, cfg: cfg, clock: clock, callback: callback,
+		buckets: make([]breakerBucket, cfg.Buckets), bucketSz: cfg.Window / time.Duration(cfg.Buckets)}
+}
+
+func checkBreakerConfig(cfg BreakerConfig) BreakerConfig {
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = 10
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 1
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	if cfg.Window < time.Duration(cfg.Buckets) || cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		panic("Circuit breaker window=" + cfg.Window.String() + " should be not less than " +
+			strconv.Itoa(cfg.Buckets) + "ns and failure ratio should be in (0..1]")
+	}
+	return cfg
+}
+
+// Allow returns nil if a request can be made, or ErrBreakerOpen. If the
+// request is allowed its result must be reported by Report() with the ticket
+// returned.
+func (cb *CircuitBreaker) Allow() (BreakerTicket, error) {
+	cb.lock.Lock()
+	from := cb.state
+	err := cb.allow()
+	to, gen := cb.state, cb.gen
+	cb.lock.Unlock()
+
+	cb.notify(from, to)
+	return gen, err
+}
+
+// Report counts result of a request which was allowed by Allow(). Every
+// allowed request must be reported, a request which is not reported in
+// half-open state holds the probe slot for the cooldown period. The result is
+// ignored if the breaker state has changed since the request was allowed,
+// so a late result of a request allowed in closed state is not taken as a
+// probe result.
+func (cb *CircuitBreaker) Report(t BreakerTicket, success bool) {
+	cb.lock.Lock()
+	from := cb.state
+	if t == cb.gen {
+		cb.report(success)
+	}
+	to := cb.state
+	cb.lock.Unlock()
+
+	cb.notify(from, to)
+}
+
+// Execute calls f if the breaker allows it and reports the result, which is
+// success if f returns nil. Panic in f is reported as a failure.
+func (cb *CircuitBreaker) Execute(f func() error) error {
+	t, err := cb.Allow()
+	if err != nil {
+		return err
+	}
+	success := false
+	defer func() {
+		cb.Report(t, success)
+	}()
+	err = f()
+	success = err == nil
+	return err
+}
+
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if cb.state == BreakerOpen && cb.cooledDown() {
+		return BreakerHalfOpen
+	}
+	return cb.state
+}
+
+func (cb *CircuitBreaker) allow() error {
+	switch cb.state {
+	case BreakerOpen:
+		if !cb.cooledDown() {
+			return ErrBreakerOpen
+		}
+		cb.setState(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if cb.probes >= cb.cfg.HalfOpenRequests {
+			now := cb.clock()
+			if now.Before(cb.probesAt.Add(cb.cfg.Cooldown)) {
+				return ErrBreakerOpen
+			}
+			// the probes are lost
+			cb.probes, cb.probesOk, cb.probesAt = 0, 0, now
+			cb.gen++
+		}
+		cb.probes++
+	}
+	return nil
+}
+
+func (cb *CircuitBreaker) report(success bool) {
+	switch cb.state {
+	case BreakerClosed:
+		b := cb.bucket()
+		b.requests++
+		if !success {
+			b.failures++
+			cb.checkRatio()
+		}
+	case BreakerHalfOpen:
+		if !success {
+			cb.setState(BreakerOpen)
+			return
+		}
+		cb.probesOk++
+		if cb.probesOk >= cb.cfg.HalfOpenRequests {
+			cb.setState(BreakerClosed)
+		}
+	}
+}
+
+func (cb *CircuitBreaker) checkRatio() {
+	epoch := cb.epoch()
+	requests, failures := 0, 0
+	for i := range cb.buckets {
+		if b := &cb.buckets[i]; b.epoch > epoch-int64(len(cb.buckets)) {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	if requests >= cb.cfg.MinRequests && float64(failures) >= cb.cfg.FailureRatio*float64(requests) {
+		cb.setState(BreakerOpen)
+	}
+}
+
+func (cb *CircuitBreaker) setState(state BreakerState) {
+	cb.state = state
+	cb.gen++
+	cb.probes = 0
+	cb.probesOk = 0
+	switch state {
+	case BreakerOpen:
+		cb.openedAt = cb.clock()
+	case BreakerHalfOpen:
+		cb.probesAt = cb.clock()
+	case BreakerClosed:
+		for i := range cb.buckets {
+			cb.buckets[i] = breakerBucket{}
+		}
+	}
+}
+
+// bucket returns the current bucket of the rolling window
+func (cb *CircuitBreaker) bucket() *breakerBucket {
+	epoch := cb.epoch()
+	b := &cb.buckets[epoch%int64(len(cb.buckets))]
+	if b.epoch != epoch {
+		*b = breakerBucket{epoch: epoch}
+	}
+	return b
+}
+
+func (cb *CircuitBreaker) epoch() int64 {
+	return cb.clock().UnixNano() / int64(cb.bucketSz)
+}
+
+func (cb *CircuitBreaker) cooledDown() bool {
+	return !cb.clock().Before(cb.openedAt.Add(cb.cfg.Cooldown))
+}
+
+func (cb *CircuitBreaker) notify(from, to BreakerState) {
+	if from != to && cb.callback != nil {
+		cb.callback(cb.key, from, to)
+	}
+}
+
+// NewBreakerRegistry creates new BreakerRegistry, where every breaker is
+// created with the cfg provided. The registry keeps up to maxKeys breakers,
+// and drops breakers which are not used for idleTimeout. The callback is
+// called for state changes of all the breakers, it can be nil.
+func NewBreakerRegistry(cfg BreakerConfig, maxKeys int64, idleTimeout time.Duration, callback BreakerCallback) *BreakerRegistry {
+	return NewBreakerRegistryWithClock(cfg, maxKeys, idleTimeout, callback, time.Now)
+}
+
+// NewBreakerRegistryWithClock creates BreakerRegistry (see NewBreakerRegistry)
+// which takes the current time from the clock provided
+func NewBreakerRegistryWithClock(cfg BreakerConfig, maxKeys int64, idleTimeout time.Duration, callback BreakerCallback,
+	clock ClockF) *BreakerRegistry {
+	return &BreakerRegistry{cfg: checkBreakerConfig(cfg), clock: clock, callback: callback,
+		lru: NewTtlLRUWithClock(maxKeys, idleTimeout, nil, clock)}
+}
+
+// Get returns the circuit breaker for the key, it is created if it doesn't
+// exist yet
+func (br *BreakerRegistry) Get(k interface{}) *CircuitBreaker {
+	br.lock.Lock()
+	defer br.lock.Unlock()
+
+	if v, ok := br.lru.Get(k); ok {
+		return v.(*CircuitBreaker)
+	}
+	cb := newCircuitBreaker(k, br.cfg, br.callback, br.clock)
+	br.lru.Add(k, cb, 1)
+	return cb
+}
+
+// Execute calls f through the circuit breaker of the key
+func (br *BreakerRegistry) Execute(k interface{}, f func() error) error {
+	return br.Get(k).Execute(f)
+}
+
+// Len returns number of tracked breakers
+func (br *BreakerRegistry) Len() int {
+	br.lock.Lock()
+	defer br.lock.Unlock()
+
+	return br.lru.Len()
+}
@@ -0,0 +1,175 @@
+package gorivets
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+type circuitBreakerSuite struct {
+}
+
+var _ = check.Suite(&circuitBreakerSuite{})
+
+type breakerEvent struct {
+	k        interface{}
+	from, to BreakerState
+}
+
+// allowErr returns the error of cb.Allow()
+func allowErr(cb *CircuitBreaker) error {
+	_, err := cb.Allow()
+	return err
+}
+
+// request is allowed and reported with the result
+func request(cb *CircuitBreaker, success bool) {
+	t, _ := cb.Allow()
+	cb.Report(t, success)
+}
+
+func newTestBreakerConfig() BreakerConfig {
+	return BreakerConfig{Window: 10 * time.Second, MinRequests: 4, FailureRatio: 0.5,
+		Cooldown: 5 * time.Second, HalfOpenRequests: 2}
+}
+
+func (s *circuitBreakerSuite) TestStates(c *check.C) {
+	now := time.Unix(1000, 0)
+	var events []breakerEvent
+	cb := NewCircuitBreakerWithClock(newTestBreakerConfig(), func(k interface{}, from, to BreakerState) {
+		events = append(events, breakerEvent{k, from, to})
+	}, func() time.Time { return now })
+	fail := errors.New("fail")
+	ok := func() error { return nil }
+	bad := func() error { return fail }
+
+	c.Assert(cb.Execute(bad), check.Equals, fail)
+	c.Assert(cb.Execute(bad), check.Equals, fail)
+	c.Assert(cb.Execute(ok), check.IsNil)
+	c.Assert(cb.State(), check.Equals, BreakerClosed)
+	c.Assert(cb.Execute(bad), check.Equals, fail)
+	c.Assert(cb.State(), check.Equals, BreakerOpen)
+	c.Assert(cb.Execute(ok), check.Equals, ErrBreakerOpen)
+
+	now = now.Add(5 * time.Second)
+	c.Assert(cb.State(), check.Equals, BreakerHalfOpen)
+	t1, err := cb.Allow()
+	c.Assert(err, check.IsNil)
+	t2, err := cb.Allow()
+	c.Assert(err, check.IsNil)
+	c.Assert(allowErr(cb), check.Equals, ErrBreakerOpen)
+	cb.Report(t1, true)
+	cb.Report(t2, false)
+	c.Assert(cb.State(), check.Equals, BreakerOpen)
+
+	now = now.Add(5 * time.Second)
+	c.Assert(cb.Execute(ok), check.IsNil)
+	c.Assert(cb.Execute(ok), check.IsNil)
+	c.Assert(cb.State(), check.Equals, BreakerClosed)
+
+	c.Assert(events, check.DeepEquals, []breakerEvent{
+		{nil, BreakerClosed, BreakerOpen},
+		{nil, BreakerOpen, BreakerHalfOpen},
+		{nil, BreakerHalfOpen, BreakerOpen},
+		{nil, BreakerOpen, BreakerHalfOpen},
+		{nil, BreakerHalfOpen, BreakerClosed},
+	})
+	c.Assert(BreakerHalfOpen.String(), check.Equals, "half-open")
+}
+
+func (s *circuitBreakerSuite) TestRollingWindow(c *check.C) {
+	now := time.Unix(1000, 0)
+	cb := NewCircuitBreakerWithClock(newTestBreakerConfig(), nil, func() time.Time { return now })
+	for i := 0; i < 3; i++ {
+		request(cb, false)
+	}
+	// the failures go out of the window
+	now = now.Add(11 * time.Second)
+	request(cb, false)
+	c.Assert(cb.State(), check.Equals, BreakerClosed)
+	for i := 0; i < 3; i++ {
+		request(cb, true)
+	}
+	c.Assert(cb.State(), check.Equals, BreakerClosed)
+	request(cb, false)
+	c.Assert(cb.State(), check.Equals, BreakerClosed)
+	request(cb, false)
+	c.Assert(cb.State(), check.Equals, BreakerOpen)
+}
+
+func (s *circuitBreakerSuite) TestLostProbes(c *check.C) {
+	now := time.Unix(1000, 0)
+	cfg := newTestBreakerConfig()
+	cfg.MinRequests = 1
+	cb := NewCircuitBreakerWithClock(cfg, nil, func() time.Time { return now })
+	request(cb, false)
+	c.Assert(cb.State(), check.Equals, BreakerOpen)
+
+	// panic is reported as failure
+	now = now.Add(5 * time.Second)
+	c.Assert(CheckPanic(func() { cb.Execute(func() error { panic("boom") }) }), check.NotNil)
+	c.Assert(cb.State(), check.Equals, BreakerOpen)
+
+	// not reported probes are let through again after the cooldown
+	now = now.Add(5 * time.Second)
+	c.Assert(allowErr(cb), check.IsNil)
+	c.Assert(allowErr(cb), check.IsNil)
+	c.Assert(allowErr(cb), check.Equals, ErrBreakerOpen)
+	now = now.Add(4 * time.Second)
+	c.Assert(allowErr(cb), check.Equals, ErrBreakerOpen)
+	now = now.Add(time.Second)
+	c.Assert(cb.Execute(func() error { return nil }), check.IsNil)
+	c.Assert(cb.Execute(func() error { return nil }), check.IsNil)
+	c.Assert(cb.State(), check.Equals, BreakerClosed)
+}
+
+func (s *circuitBreakerSuite) TestLateReport(c *check.C) {
+	now := time.Unix(1000, 0)
+	cfg := newTestBreakerConfig()
+	cfg.MinRequests = 1
+	cfg.HalfOpenRequests = 1
+	cb := NewCircuitBreakerWithClock(cfg, nil, func() time.Time { return now })
+
+	ta, _ := cb.Allow()
+	request(cb, false)
+	c.Assert(cb.State(), check.Equals, BreakerOpen)
+
+	now = now.Add(5 * time.Second)
+	probe, err := cb.Allow()
+	c.Assert(err, check.IsNil)
+	// the request allowed in closed state is not taken as the probe
+	cb.Report(ta, true)
+	c.Assert(cb.State(), check.Equals, BreakerHalfOpen)
+	c.Assert(allowErr(cb), check.Equals, ErrBreakerOpen)
+
+	cb.Report(probe, true)
+	c.Assert(cb.State(), check.Equals, BreakerClosed)
+}
+
+func (s *circuitBreakerSuite) TestRegistry(c *check.C) {
+	now := time.Unix(1000, 0)
+	var events []breakerEvent
+	cfg := newTestBreakerConfig()
+	cfg.MinRequests = 1
+	br := NewBreakerRegistryWithClock(cfg, 2, time.Minute, func(k interface{}, from, to BreakerState) {
+		events = append(events, breakerEvent{k, from, to})
+	}, func() time.Time { return now })
+
+	br.Execute("a", func() error { return errors.New("fail") })
+	c.Assert(br.Get("a").State(), check.Equals, BreakerOpen)
+	c.Assert(br.Get("b").State(), check.Equals, BreakerClosed)
+	c.Assert(events, check.DeepEquals, []breakerEvent{{"a", BreakerClosed, BreakerOpen}})
+	c.Assert(br.Get("a"), check.Equals, br.Get("a"))
+
+	br.Get("c")
+	c.Assert(br.Len(), check.Equals, 2)
+
+	now = now.Add(2 * time.Minute)
+	br.Get("d")
+	c.Assert(br.Len(), check.Equals, 1)
+	c.Assert(br.Get("a").State(), check.Equals, BreakerClosed)
+
+	c.Assert(CheckPanic(func() { NewCircuitBreaker(BreakerConfig{Window: time.Second}, nil) }), check.NotNil)
+	c.Assert(CheckPanic(func() { NewCircuitBreakerWithClock(newTestBreakerConfig(), nil, nil) }), check.NotNil)
+}
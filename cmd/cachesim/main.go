@@ -0,0 +1,370 @@
+// cachesim replays a cache access trace against gorivets cache implementations
+// with different capacities and prints hit ratio and byte hit ratio for each
+// of them.
+//
+// The trace is a text file where every line is either
+//
+//	<key> [<size>]
+//
+// or a CSV record
+//
+//	<timestamp>,<key>[,<size>]
+//
+// The format is detected by the first line of the trace: it is CSV if the
+// line has a timestamp before the first comma, all lines must have the same
+// format.
+//
+// The timestamp is RFC3339 time or Unix time in seconds (fractions are
+// allowed). When timestamps are present, time-based caches are driven by them
+// instead of the wall clock. Size is 1 if not specified. Empty lines and lines
+// started from '#' are skipped.
+//
+// A policy which cannot run with a capacity (arena needs at least 4096 bytes)
+// is reported as n/a for the capacity.
+//
+// Usage:
+//
+//	cachesim -sizes 1k,10k,100k -policies lru,ttl,arena -ttl 5m [-csv] trace.txt
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jrivets/gorivets"
+)
+
+type (
+	access struct {
+		key  string
+		size int64
+		ts   time.Time
+	}
+
+	// simCache is the simulated cache, it is built for every policy and
+	// capacity
+	simCache interface {
+		// returns whether the key was in the cache, adds it if it was not
+		access(a *access) bool
+	}
+
+	newSimCacheF func(capacity int64, ttl time.Duration, clock gorivets.ClockF) (simCache, error)
+
+	result struct {
+		policy   string
+		capacity int64
+		requests int64
+		hits     int64
+		bytes    int64
+		byteHits int64
+		// the policy doesn't support the capacity
+		na bool
+	}
+)
+
+const (
+	cDefaultSizes    = "1k,10k,100k"
+	cDefaultPolicies = "lru,ttl,arena"
+)
+
+// errCapacity is returned by newSimCacheF when the policy doesn't support the
+// capacity, it is reported as n/a instead of failing the run
+var errCapacity = errors.New("the capacity is not supported by the policy")
+
+var policies = map[string]newSimCacheF{
+	"lru":   newLruSim,
+	"ttl":   newTtlSim,
+	"arena": newArenaSim,
+}
+
+func main() {
+	sizes := flag.String("sizes", cDefaultSizes, "comma separated cache capacities, suffixes like k, mb, kib are allowed")
+	pols := flag.String("policies", cDefaultPolicies, "comma separated cache policies: lru, ttl, arena")
+	ttl := flag.Duration("ttl", 5*time.Minute, "entries time-to-live for the ttl policy")
+	csvOut := flag.Bool("csv", false, "print results in CSV format")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <trace file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	caps, err := parseSizes(*sizes)
+	exitOnError(err)
+	names, err := parsePolicies(*pols)
+	exitOnError(err)
+
+	f, err := os.Open(flag.Arg(0))
+	exitOnError(err)
+	trace, err := parseTrace(f)
+	f.Close()
+	exitOnError(err)
+
+	var res []*result
+	for _, name := range names {
+		for _, c := range caps {
+			r, err := simulate(name, c, *ttl, trace)
+			exitOnError(err)
+			res = append(res, r)
+		}
+	}
+
+	if *csvOut {
+		exitOnError(printCsv(os.Stdout, res))
+	} else {
+		printTable(os.Stdout, res)
+	}
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cachesim:", err)
+		os.Exit(1)
+	}
+}
+
+func parseSizes(sizes string) ([]int64, error) {
+	var res []int64
+	for _, s := range strings.Split(sizes, ",") {
+		if strings.TrimSpace(s) == "" {
+			return nil, errors.New("empty capacity in \"" + sizes + "\"")
+		}
+		v, err := gorivets.ParseInt64(s, 1, math.MaxInt64, 1)
+		if err != nil {
+			return nil, errors.New("wrong capacity \"" + s + "\": " + err.Error())
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+func parsePolicies(pols string) ([]string, error) {
+	var res []string
+	for _, p := range strings.Split(pols, ",") {
+		p = strings.TrimSpace(p)
+		if _, ok := policies[p]; !ok {
+			return nil, errors.New("unknown policy \"" + p + "\"")
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+func parseTrace(r io.Reader) ([]access, error) {
+	var res []access
+	sc := bufio.NewScanner(r)
+	ln := 0
+	csvFmt := false
+	for sc.Scan() {
+		ln++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if len(res) == 0 {
+			csvFmt = isCsvLine(line)
+		}
+		a, err := parseLine(line, csvFmt)
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(ln) + ": " + err.Error())
+		}
+		res = append(res, a)
+	}
+	return res, sc.Err()
+}
+
+// isCsvLine returns whether the line is a CSV record started from timestamp
+func isCsvLine(line string) bool {
+	idx := strings.IndexByte(line, ',')
+	if idx < 0 {
+		return false
+	}
+	_, err := parseTimestamp(strings.TrimSpace(line[:idx]))
+	return err == nil
+}
+
+func parseLine(line string, csvFmt bool) (access, error) {
+	var a access
+	var fields []string
+	if csvFmt {
+		rec, err := csv.NewReader(strings.NewReader(line)).Read()
+		if err != nil {
+			return a, err
+		}
+		if len(rec) < 2 || len(rec) > 3 {
+			return a, errors.New("expecting <timestamp>,<key>[,<size>]")
+		}
+		ts, err := parseTimestamp(strings.TrimSpace(rec[0]))
+		if err != nil {
+			return a, err
+		}
+		a.ts = ts
+		fields = rec[1:]
+	} else {
+		fields = strings.Fields(line)
+		if len(fields) > 2 {
+			return a, errors.New("expecting <key> [<size>]")
+		}
+	}
+
+	a.key = strings.TrimSpace(fields[0])
+	a.size = 1
+	if len(fields) == 2 {
+		size, err := gorivets.ParseInt64(fields[1], 0, math.MaxInt64, 1)
+		if err != nil {
+			return a, err
+		}
+		a.size = size
+	}
+	return a, nil
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func simulate(policy string, capacity int64, ttl time.Duration, trace []access) (*result, error) {
+	var now time.Time
+	clock := func() time.Time { return now }
+	res := &result{policy: policy, capacity: capacity}
+	sc, err := policies[policy](capacity, ttl, clock)
+	if err == errCapacity {
+		res.na = true
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range trace {
+		a := &trace[i]
+		if a.ts.IsZero() {
+			now = time.Now()
+		} else {
+			now = a.ts
+		}
+		res.requests++
+		res.bytes += a.size
+		if sc.access(a) {
+			res.hits++
+			res.byteHits += a.size
+		}
+	}
+	return res, nil
+}
+
+func (r *result) hitRatio() float64 {
+	return ratio(r.hits, r.requests)
+}
+
+func (r *result) byteHitRatio() float64 {
+	return ratio(r.byteHits, r.bytes)
+}
+
+func ratio(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func printTable(w io.Writer, res []*result) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "policy\tcapacity\trequests\thits\thit ratio\tbyte hit ratio\t")
+	for _, r := range res {
+		if r.na {
+			fmt.Fprintf(tw, "%s\t%s\tn/a\tn/a\tn/a\tn/a\t\n", r.policy, gorivets.FormatInt64(r.capacity, 1000))
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.4f\t%.4f\t\n", r.policy, gorivets.FormatInt64(r.capacity, 1000),
+			r.requests, r.hits, r.hitRatio(), r.byteHitRatio())
+	}
+	tw.Flush()
+}
+
+func printCsv(w io.Writer, res []*result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"policy", "capacity", "requests", "hits", "hit_ratio", "byte_hit_ratio"})
+	for _, r := range res {
+		if r.na {
+			cw.Write([]string{r.policy, strconv.FormatInt(r.capacity, 10), "n/a", "n/a", "n/a", "n/a"})
+			continue
+		}
+		cw.Write([]string{r.policy, strconv.FormatInt(r.capacity, 10), strconv.FormatInt(r.requests, 10),
+			strconv.FormatInt(r.hits, 10), strconv.FormatFloat(r.hitRatio(), 'f', 6, 64),
+			strconv.FormatFloat(r.byteHitRatio(), 'f', 6, 64)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ================================ policies ==================================
+type lruSim struct {
+	lru gorivets.LRU
+}
+
+func newLruSim(capacity int64, ttl time.Duration, clock gorivets.ClockF) (simCache, error) {
+	return &lruSim{gorivets.NewLRU(capacity, nil)}, nil
+}
+
+func newTtlSim(capacity int64, ttl time.Duration, clock gorivets.ClockF) (simCache, error) {
+	return &lruSim{gorivets.NewTtlLRUWithClock(capacity, ttl, nil, clock)}, nil
+}
+
+func (ls *lruSim) access(a *access) bool {
+	// time-based LRU doesn't check expiration on Get
+	ls.lru.Sweep()
+	if _, ok := ls.lru.Get(a.key); ok {
+		return true
+	}
+	ls.lru.Add(a.key, nil, a.size)
+	return false
+}
+
+const cMinArenaSize = 4096
+
+// arenaSim stores values of the access size, so the arena capacity includes
+// keys and entry headers
+type arenaSim struct {
+	ac  *gorivets.ArenaCache
+	val []byte
+}
+
+func newArenaSim(capacity int64, ttl time.Duration, clock gorivets.ClockF) (simCache, error) {
+	if capacity < cMinArenaSize {
+		return nil, errCapacity
+	}
+	seg := capacity / 16
+	if seg < cMinArenaSize {
+		seg = cMinArenaSize
+	}
+	return &arenaSim{ac: gorivets.NewArenaCache(capacity, int(seg))}, nil
+}
+
+func (as *arenaSim) access(a *access) bool {
+	k := []byte(a.key)
+	if _, ok := as.ac.Get(k); ok {
+		return true
+	}
+	if int64(len(as.val)) < a.size {
+		as.val = make([]byte, a.size)
+	}
+	as.ac.Add(k, as.val[:a.size])
+	return false
+}
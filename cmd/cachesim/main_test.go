@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTrace(t *testing.T) {
+	tr, err := parseTrace(strings.NewReader("# comment\na 10\n\nb\nc,d 2kb\n"))
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	if len(tr) != 3 {
+		t.Fatal("expecting 3 accesses, but got ", len(tr))
+	}
+	if tr[0].key != "a" || tr[0].size != 10 || !tr[0].ts.IsZero() {
+		t.Fatal("wrong first access ", tr[0])
+	}
+	if tr[1].key != "b" || tr[1].size != 1 {
+		t.Fatal("wrong second access ", tr[1])
+	}
+	if tr[2].key != "c,d" || tr[2].size != 2000 || !tr[2].ts.IsZero() {
+		t.Fatal("wrong third access ", tr[2])
+	}
+
+	tr, err = parseTrace(strings.NewReader("# comment\n1000.5,c,2kb\n2017-01-02T10:00:00Z,\"d,e\"\n"))
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	if tr[0].key != "c" || tr[0].size != 2000 || !tr[0].ts.Equal(time.Unix(1000, 500000000)) {
+		t.Fatal("wrong first CSV access ", tr[0])
+	}
+	if tr[1].key != "d,e" || tr[1].ts.Year() != 2017 {
+		t.Fatal("wrong second CSV access ", tr[1])
+	}
+
+	if _, err := parseTrace(strings.NewReader("a b c\n")); err == nil {
+		t.Fatal("expecting error for wrong line")
+	}
+	if _, err := parseTrace(strings.NewReader("1000,a\nyesterday,a\n")); err == nil {
+		t.Fatal("expecting error for wrong timestamp")
+	}
+	if _, err := parseTrace(strings.NewReader("a 1\n1000,b\n")); err != nil {
+		t.Fatal("expecting CSV-like line to be parsed as plain key, but got ", err)
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := parseSizes("1k,10k")
+	if err != nil || len(sizes) != 2 || sizes[0] != 1000 || sizes[1] != 10000 {
+		t.Fatal("unexpected sizes ", sizes, err)
+	}
+	if _, err := parseSizes("1k,,10k"); err == nil {
+		t.Fatal("expecting error for empty capacity")
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	tr, _ := parseTrace(strings.NewReader("a 1\nb 1\na 1\nc 3\na 1\nb 1\n"))
+	r, err := simulate("lru", 4, time.Minute, tr)
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	// a, b, a(hit), c (evicts b), a(hit), b
+	if r.requests != 6 || r.hits != 2 || r.byteHits != 2 || r.bytes != 8 {
+		t.Fatal("unexpected result ", *r)
+	}
+
+	tr, _ = parseTrace(strings.NewReader("0,a\n30,a\n100,a\n"))
+	r, _ = simulate("ttl", 10, time.Minute, tr)
+	if r.hits != 1 {
+		t.Fatal("expecting 1 hit for ttl policy, but got ", r.hits)
+	}
+
+	r, _ = simulate("arena", 4096, 0, tr)
+	if r.hits != 2 {
+		t.Fatal("expecting 2 hits for arena policy, but got ", r.hits)
+	}
+	small, err := simulate("arena", 10, 0, tr)
+	if err != nil || !small.na {
+		t.Fatal("expecting too small arena is reported as n/a, but got ", small, err)
+	}
+
+	var buf bytes.Buffer
+	printCsv(&buf, []*result{r, small})
+	if buf.String() != "policy,capacity,requests,hits,hit_ratio,byte_hit_ratio\narena,4096,3,2,0.666667,0.666667\n"+
+		"arena,10,n/a,n/a,n/a,n/a\n" {
+		t.Fatal("unexpected CSV output ", buf.String())
+	}
+}
+
+func TestSimulateDefaults(t *testing.T) {
+	caps, err := parseSizes(cDefaultSizes)
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	names, err := parsePolicies(cDefaultPolicies)
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	tr, _ := parseTrace(strings.NewReader("a 10\nb 100\na 10\nc 1k\na 10\n"))
+	for _, name := range names {
+		for _, c := range caps {
+			r, err := simulate(name, c, time.Minute, tr)
+			if err != nil {
+				t.Fatal("unexpected error for policy=", name, " capacity=", c, ": ", err)
+			}
+			if !r.na && r.requests != 5 {
+				t.Fatal("expecting the whole trace is replayed, but got ", *r)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package gorivets
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentSortedSlice is goroutine-safe wrapper of SortedSlice. It works in
+// one of 2 modes:
+//
+// - RWMutex mode: readers and writers share the collection guarded by
+// sync.RWMutex, so readers wait for writers and vice versa;
+//
+// - copy-on-write mode: every modification copies the collection and
+// publishes the new version atomically. Readers never block and never see
+// a collection in the middle of a modification, but every modification
+// takes O(n).
+//
+// Snapshot() returns a consistent view of the collection, which can be
+// iterated without holding any locks.
+type ConcurrentSortedSlice[T any] struct {
+	lock sync.RWMutex
+	ss   *SortedSlice[T]
+	cow  bool
+	snap atomic.Pointer[SortedSlice[T]]
+}
+
+// NewConcurrentSortedSlice creates ConcurrentSortedSlice over ss, which must
+// not be used directly after the call.
+func NewConcurrentSortedSlice[T any](ss *SortedSlice[T], copyOnWrite bool) *ConcurrentSortedSlice[T] {
+	cs := &ConcurrentSortedSlice[T]{ss: ss, cow: copyOnWrite}
+	if copyOnWrite {
+		cs.snap.Store(ss)
+	}
+	return cs
+}
+
+// Read calls f with the collection for reading, f must not modify it
+func (cs *ConcurrentSortedSlice[T]) Read(f func(ss *SortedSlice[T])) {
+	if cs.cow {
+		f(cs.snap.Load())
+		return
+	}
+	cs.lock.RLock()
+	defer cs.lock.RUnlock()
+	f(cs.ss)
+}
+
+// Write calls f with the collection for modification
+func (cs *ConcurrentSortedSlice[T]) Write(f func(ss *SortedSlice[T])) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	if cs.cow {
+		ss := cs.snap.Load().clone()
+		f(ss)
+		cs.snap.Store(ss)
+		return
+	}
+	f(cs.ss)
+}
+
+// Snapshot returns a consistent view of the collection, which must not be
+// modified. It is not copied in copy-on-write mode.
+func (cs *ConcurrentSortedSlice[T]) Snapshot() *SortedSlice[T] {
+	if cs.cow {
+		return cs.snap.Load()
+	}
+	cs.lock.RLock()
+	defer cs.lock.RUnlock()
+	return cs.ss.clone()
+}
+
+func (cs *ConcurrentSortedSlice[T]) Add(val T) (idx int, err error) {
+	cs.Write(func(ss *SortedSlice[T]) { idx, err = ss.Add(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) AddAll(values ...T) (n int, err error) {
+	cs.Write(func(ss *SortedSlice[T]) { n, err = ss.AddAll(values...) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) Delete(val T) (res bool) {
+	cs.Write(func(ss *SortedSlice[T]) { res = ss.Delete(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) DeleteAll(val T) (n int) {
+	cs.Write(func(ss *SortedSlice[T]) { n = ss.DeleteAll(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) DeleteAt(idx int) (val T) {
+	cs.Write(func(ss *SortedSlice[T]) { val = ss.DeleteAt(idx) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) DeleteIf(pred func(val T) bool) (n int) {
+	cs.Write(func(ss *SortedSlice[T]) { n = ss.DeleteIf(pred) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) TruncateBefore(val T) (n int) {
+	cs.Write(func(ss *SortedSlice[T]) { n = ss.TruncateBefore(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) TruncateAfter(val T) (n int) {
+	cs.Write(func(ss *SortedSlice[T]) { n = ss.TruncateAfter(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) Len() (n int) {
+	cs.Read(func(ss *SortedSlice[T]) { n = ss.Len() })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) At(idx int) (val T) {
+	cs.Read(func(ss *SortedSlice[T]) { val = ss.At(idx) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) Find(val T) (idx int, ok bool) {
+	cs.Read(func(ss *SortedSlice[T]) { idx, ok = ss.Find(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) Count(val T) (n int) {
+	cs.Read(func(ss *SortedSlice[T]) { n = ss.Count(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) First() (val T, ok bool) {
+	cs.Read(func(ss *SortedSlice[T]) { val, ok = ss.First() })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) Last() (val T, ok bool) {
+	cs.Read(func(ss *SortedSlice[T]) { val, ok = ss.Last() })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) GetInsertPos(val T) (idx int) {
+	cs.Read(func(ss *SortedSlice[T]) { idx = ss.GetInsertPos(val) })
+	return
+}
+
+func (cs *ConcurrentSortedSlice[T]) Copy() (res []T) {
+	cs.Read(func(ss *SortedSlice[T]) { res = ss.Copy() })
+	return
+}
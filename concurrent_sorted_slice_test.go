@@ -0,0 +1,72 @@
+package gorivets
+
+import (
+	"sync"
+
+	"gopkg.in/check.v1"
+)
+
+type concurrentSortedSliceSuite struct {
+}
+
+var _ = check.Suite(&concurrentSortedSliceSuite{})
+
+func (s *concurrentSortedSliceSuite) TestOperations(c *check.C) {
+	for _, cow := range []bool{false, true} {
+		cs := NewConcurrentSortedSlice(newIntSortedSlice(), cow)
+		cs.Add(3)
+		cs.AddAll(1, 2, 2)
+		c.Assert(cs.Copy(), check.DeepEquals, []int{1, 2, 2, 3})
+		c.Assert(cs.Len(), check.Equals, 4)
+		c.Assert(cs.At(1), check.Equals, 2)
+		c.Assert(cs.Count(2), check.Equals, 2)
+		c.Assert(cs.GetInsertPos(2), check.Equals, 3)
+		idx, ok := cs.Find(3)
+		c.Assert(ok, check.Equals, true)
+		c.Assert(idx, check.Equals, 3)
+
+		snap := cs.Snapshot()
+		c.Assert(cs.DeleteAll(2), check.Equals, 2)
+		c.Assert(cs.Delete(1), check.Equals, true)
+		c.Assert(cs.DeleteAt(0), check.Equals, 3)
+		c.Assert(cs.Len(), check.Equals, 0)
+		_, ok = cs.First()
+		c.Assert(ok, check.Equals, false)
+		_, ok = cs.Last()
+		c.Assert(ok, check.Equals, false)
+		c.Assert(snap.Copy(), check.DeepEquals, []int{1, 2, 2, 3})
+	}
+}
+
+func (s *concurrentSortedSliceSuite) TestConcurrent(c *check.C) {
+	for _, cow := range []bool{false, true} {
+		cs := NewConcurrentSortedSlice(newIntSortedSlice(), cow)
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(2)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					cs.Add(g*1000 + i)
+					if i%2 == 0 {
+						cs.Delete(g*1000 + i)
+					}
+				}
+			}(g)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					cs.Find(i)
+					snap := cs.Snapshot()
+					for j := 1; j < snap.Len(); j++ {
+						if snap.At(j-1) > snap.At(j) {
+							c.Error("the snapshot is not sorted")
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		c.Assert(cs.Len(), check.Equals, 400)
+	}
+}
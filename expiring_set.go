@@ -0,0 +1,106 @@
+package gorivets
+
+import (
+	"sync"
+	"time"
+)
+
+// ExpiringSet keeps keys for the ttl duration since they were added. The set
+// is bounded by size, the oldest keys are dropped when the size is exceeded.
+// It is built on the time-based LRU and suitable for remembering recently
+// seen message IDs or idempotency keys:
+//
+//	if !seen.AddIfAbsent(msg.Id) {
+//		// duplicate, skip it
+//	}
+//
+// Multithread: friendly
+type ExpiringSet struct {
+	lock sync.Mutex
+	lru  LRU
+}
+
+// NewExpiringSet creates new ExpiringSet which keeps keys for ttl. Every key
+// added by AddIfAbsent() has size 1, so maxSize is the maximum number of keys
+// in the case.
+func NewExpiringSet(maxSize int64, ttl time.Duration) *ExpiringSet {
+	return NewExpiringSetWithClock(maxSize, ttl, time.Now)
+}
+
+// NewExpiringSetWithClock creates ExpiringSet (see NewExpiringSet) which takes
+// the current time from the clock provided
+func NewExpiringSetWithClock(maxSize int64, ttl time.Duration, clock ClockF) *ExpiringSet {
+	return &ExpiringSet{lru: NewTtlLRUWithClock(maxSize, ttl, nil, clock)}
+}
+
+// AddIfAbsent adds the key to the set if it is not there. Returns true if the
+// key was added, and false if the key is already in the set.
+func (es *ExpiringSet) AddIfAbsent(k interface{}) bool {
+	return es.AddIfAbsentWithSize(k, 1)
+}
+
+// AddIfAbsentWithSize is the same as AddIfAbsent, but the key has the size
+// provided
+func (es *ExpiringSet) AddIfAbsentWithSize(k interface{}, size int64) bool {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	es.lru.Sweep()
+	if _, ok := es.lru.Peek(k); ok {
+		return false
+	}
+	es.lru.Add(k, nil, size)
+	return true
+}
+
+// Contains returns whether the key is in the set. It doesn't prolong the key
+// life.
+func (es *ExpiringSet) Contains(k interface{}) bool {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	es.lru.Sweep()
+	_, ok := es.lru.Peek(k)
+	return ok
+}
+
+// Delete removes the key from the set. Returns whether the key was there.
+func (es *ExpiringSet) Delete(k interface{}) bool {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	es.lru.Sweep()
+	_, ok := es.lru.Peek(k)
+	es.lru.DeleteWithCallback(k, false)
+	return ok
+}
+
+// Sweep removes expired keys. It is not necessary to call the method, but it
+// allows to release the memory if the set is not used for a while.
+func (es *ExpiringSet) Sweep() {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	es.lru.Sweep()
+}
+
+func (es *ExpiringSet) Clear() {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	es.lru.Clear()
+}
+
+func (es *ExpiringSet) Len() int {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	return es.lru.Len()
+}
+
+func (es *ExpiringSet) Size() int64 {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	return es.lru.Size()
+}
@@ -0,0 +1,71 @@
+package gorivets
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+type expiringSetSuite struct {
+}
+
+var _ = check.Suite(&expiringSetSuite{})
+
+func (s *expiringSetSuite) TestAddIfAbsent(c *check.C) {
+	now := time.Now()
+	es := NewExpiringSetWithClock(10, time.Minute, func() time.Time { return now })
+
+	c.Assert(es.AddIfAbsent("a"), check.Equals, true)
+	c.Assert(es.AddIfAbsent("a"), check.Equals, false)
+	c.Assert(es.Contains("a"), check.Equals, true)
+	c.Assert(es.Contains("b"), check.Equals, false)
+
+	now = now.Add(30 * time.Second)
+	c.Assert(es.AddIfAbsent("b"), check.Equals, true)
+	now = now.Add(31 * time.Second)
+	c.Assert(es.Contains("a"), check.Equals, false)
+	c.Assert(es.Contains("b"), check.Equals, true)
+	c.Assert(es.AddIfAbsent("a"), check.Equals, true)
+	c.Assert(es.Len(), check.Equals, 2)
+
+	c.Assert(es.Delete("a"), check.Equals, true)
+	c.Assert(es.Delete("a"), check.Equals, false)
+	es.Clear()
+	c.Assert(es.Len(), check.Equals, 0)
+}
+
+func (s *expiringSetSuite) TestBounded(c *check.C) {
+	es := NewExpiringSet(3, time.Hour)
+	for i := 0; i < 5; i++ {
+		es.AddIfAbsent(i)
+	}
+	c.Assert(es.Len(), check.Equals, 3)
+	c.Assert(es.Contains(1), check.Equals, false)
+	c.Assert(es.Contains(2), check.Equals, true)
+
+	c.Assert(es.AddIfAbsentWithSize("big", 2), check.Equals, true)
+	c.Assert(es.Len(), check.Equals, 2)
+	c.Assert(es.Size(), check.Equals, int64(3))
+}
+
+func (s *expiringSetSuite) TestConcurrent(c *check.C) {
+	es := NewExpiringSet(1000, time.Hour)
+	var added int32
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if es.AddIfAbsent("k" + strconv.Itoa(i)) {
+					atomic.AddInt32(&added, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(added, check.Equals, int32(100))
+}
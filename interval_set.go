@@ -0,0 +1,240 @@
+package gorivets
+
+import (
+	"cmp"
+	"slices"
+)
+
+type (
+	// Interval is half-open range [From, To) of a domain ordered by a
+	// comparator. The interval is empty if From is not less than To.
+	Interval[T any] struct {
+		From, To T
+	}
+
+	// IntervalMap associates values with non-overlapping intervals. Putting
+	// a value for an interval overwrites the values of the overlapped parts
+	// of other intervals, which are split if needed. The intervals are kept in
+	// SortedSlice, so lookups take O(log n).
+	IntervalMap[T, V any] struct {
+		compF func(a, b T) int
+		ss    *SortedSlice[intervalEntry[T, V]]
+	}
+
+	// IntervalSet keeps non-overlapping intervals, overlapping and adjacent
+	// intervals are merged when added, and intervals are split when a part of
+	// them is removed.
+	IntervalSet[T any] struct {
+		m *IntervalMap[T, struct{}]
+	}
+
+	intervalEntry[T, V any] struct {
+		iv  Interval[T]
+		val V
+	}
+)
+
+// NewIntervalMap creates an empty IntervalMap over the domain ordered by compF
+func NewIntervalMap[T, V any](compF func(a, b T) int) *IntervalMap[T, V] {
+	entryCompF := func(a, b intervalEntry[T, V]) int {
+		return compF(a.iv.From, b.iv.From)
+	}
+	ss, _ := NewSortedSliceFunc(entryCompF, 10)
+	return &IntervalMap[T, V]{compF: compF, ss: ss}
+}
+
+// NewIntervalMapOrdered creates an empty IntervalMap over the domain with
+// natural ordering
+func NewIntervalMapOrdered[T cmp.Ordered, V any]() *IntervalMap[T, V] {
+	return NewIntervalMap[T, V](cmp.Compare[T])
+}
+
+// Len returns number of the intervals in the map
+func (m *IntervalMap[T, V]) Len() int {
+	return m.ss.Len()
+}
+
+// Put associates v with the interval iv, the empty interval is ignored
+func (m *IntervalMap[T, V]) Put(iv Interval[T], v V) {
+	if m.isEmpty(iv) {
+		return
+	}
+	m.Remove(iv)
+	m.ss.insertAt(LowerBoundBy(m.ss, iv.From, m.byFrom), intervalEntry[T, V]{iv: iv, val: v})
+}
+
+// Get returns value of the interval which contains the point
+func (m *IntervalMap[T, V]) Get(point T) (V, bool) {
+	idx := UpperBoundBy(m.ss, point, m.byFrom) - 1
+	if idx >= 0 && m.compF(point, m.ss.data[idx].iv.To) < 0 {
+		return m.ss.data[idx].val, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Remove removes the interval iv from the map, the intervals partially
+// overlapped by iv are truncated or split
+func (m *IntervalMap[T, V]) Remove(iv Interval[T]) {
+	if m.isEmpty(iv) {
+		return
+	}
+	l, h := m.overlapping(iv)
+	if l >= h {
+		return
+	}
+
+	pieces := make([]intervalEntry[T, V], 0, 2)
+	if first := m.ss.data[l]; m.compF(first.iv.From, iv.From) < 0 {
+		pieces = append(pieces, intervalEntry[T, V]{iv: Interval[T]{first.iv.From, iv.From}, val: first.val})
+	}
+	if last := m.ss.data[h-1]; m.compF(last.iv.To, iv.To) > 0 {
+		pieces = append(pieces, intervalEntry[T, V]{iv: Interval[T]{iv.To, last.iv.To}, val: last.val})
+	}
+	m.ss.data = slices.Replace(m.ss.data, l, h, pieces...)
+}
+
+// Overlaps returns whether any interval of the map overlaps iv
+func (m *IntervalMap[T, V]) Overlaps(iv Interval[T]) bool {
+	if m.isEmpty(iv) {
+		return false
+	}
+	l, h := m.overlapping(iv)
+	return l < h
+}
+
+// Gaps returns parts of the interval within, which are not covered by the
+// map intervals, in ascending order
+func (m *IntervalMap[T, V]) Gaps(within Interval[T]) []Interval[T] {
+	var res []Interval[T]
+	if m.isEmpty(within) {
+		return res
+	}
+	l, h := m.overlapping(within)
+	cur := within.From
+	for i := l; i < h; i++ {
+		iv := m.ss.data[i].iv
+		if m.compF(cur, iv.From) < 0 {
+			res = append(res, Interval[T]{cur, iv.From})
+		}
+		cur = iv.To
+	}
+	if m.compF(cur, within.To) < 0 {
+		res = append(res, Interval[T]{cur, within.To})
+	}
+	return res
+}
+
+// ForEach calls f for every interval and its value in ascending order until
+// f returns false. The map must not be modified by f.
+func (m *IntervalMap[T, V]) ForEach(f func(iv Interval[T], v V) bool) {
+	m.forEach(0, m.ss.Len(), f)
+}
+
+// Range calls f for the intervals overlapping iv in ascending order until
+// f returns false. The map must not be modified by f.
+func (m *IntervalMap[T, V]) Range(iv Interval[T], f func(iv Interval[T], v V) bool) {
+	if m.isEmpty(iv) {
+		return
+	}
+	l, h := m.overlapping(iv)
+	m.forEach(l, h, f)
+}
+
+func (m *IntervalMap[T, V]) forEach(l, h int, f func(iv Interval[T], v V) bool) {
+	for i := l; i < h; i++ {
+		if !f(m.ss.data[i].iv, m.ss.data[i].val) {
+			return
+		}
+	}
+}
+
+// overlapping returns span [l, h) of indexes of the intervals overlapping
+// not empty iv
+func (m *IntervalMap[T, V]) overlapping(iv Interval[T]) (int, int) {
+	l := UpperBoundBy(m.ss, iv.From, m.byTo)
+	h := LowerBoundBy(m.ss, iv.To, m.byFrom)
+	return l, Max(l, h)
+}
+
+func (m *IntervalMap[T, V]) isEmpty(iv Interval[T]) bool {
+	return m.compF(iv.From, iv.To) >= 0
+}
+
+func (m *IntervalMap[T, V]) byFrom(k T, e intervalEntry[T, V]) int {
+	return m.compF(k, e.iv.From)
+}
+
+func (m *IntervalMap[T, V]) byTo(k T, e intervalEntry[T, V]) int {
+	return m.compF(k, e.iv.To)
+}
+
+// NewIntervalSet creates an empty IntervalSet over the domain ordered by
+// compF
+func NewIntervalSet[T any](compF func(a, b T) int) *IntervalSet[T] {
+	return &IntervalSet[T]{m: NewIntervalMap[T, struct{}](compF)}
+}
+
+// NewIntervalSetOrdered creates an empty IntervalSet over the domain with
+// natural ordering
+func NewIntervalSetOrdered[T cmp.Ordered]() *IntervalSet[T] {
+	return NewIntervalSet(cmp.Compare[T])
+}
+
+// Len returns number of the intervals in the set
+func (s *IntervalSet[T]) Len() int {
+	return s.m.Len()
+}
+
+// Add adds the interval to the set merging it with overlapping and adjacent
+// intervals, the empty interval is ignored
+func (s *IntervalSet[T]) Add(iv Interval[T]) {
+	m := s.m
+	if m.isEmpty(iv) {
+		return
+	}
+	// intervals which end at iv.From or later, and start at iv.To or earlier
+	l := LowerBoundBy(m.ss, iv.From, m.byTo)
+	h := UpperBoundBy(m.ss, iv.To, m.byFrom)
+	if l < h {
+		if first := m.ss.data[l].iv; m.compF(first.From, iv.From) < 0 {
+			iv.From = first.From
+		}
+		if last := m.ss.data[h-1].iv; m.compF(last.To, iv.To) > 0 {
+			iv.To = last.To
+		}
+	}
+	m.ss.data = slices.Replace(m.ss.data, l, Max(l, h), intervalEntry[T, struct{}]{iv: iv})
+}
+
+// Remove removes the interval from the set, the intervals partially
+// overlapped by iv are truncated or split
+func (s *IntervalSet[T]) Remove(iv Interval[T]) {
+	s.m.Remove(iv)
+}
+
+// Contains returns whether the point is in one of the set intervals
+func (s *IntervalSet[T]) Contains(point T) bool {
+	_, ok := s.m.Get(point)
+	return ok
+}
+
+// Overlaps returns whether any interval of the set overlaps iv
+func (s *IntervalSet[T]) Overlaps(iv Interval[T]) bool {
+	return s.m.Overlaps(iv)
+}
+
+// Gaps returns parts of the interval within, which are not covered by the
+// set, in ascending order
+func (s *IntervalSet[T]) Gaps(within Interval[T]) []Interval[T] {
+	return s.m.Gaps(within)
+}
+
+// Intervals returns all intervals of the set in ascending order
+func (s *IntervalSet[T]) Intervals() []Interval[T] {
+	res := make([]Interval[T], 0, s.m.Len())
+	for _, e := range s.m.ss.data {
+		res = append(res, e.iv)
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package gorivets
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type intervalSetSuite struct {
+}
+
+var _ = check.Suite(&intervalSetSuite{})
+
+type intInterval = Interval[int]
+
+func (s *intervalSetSuite) TestAdd(c *check.C) {
+	is := NewIntervalSetOrdered[int]()
+	is.Add(intInterval{10, 20})
+	is.Add(intInterval{30, 40})
+	is.Add(intInterval{50, 50})
+	is.Add(intInterval{60, 55})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{10, 20}, {30, 40}})
+
+	is.Add(intInterval{0, 5})
+	is.Add(intInterval{45, 50})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{0, 5}, {10, 20}, {30, 40}, {45, 50}})
+
+	// adjacent intervals are merged
+	is.Add(intInterval{5, 10})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{0, 20}, {30, 40}, {45, 50}})
+	is.Add(intInterval{35, 46})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{0, 20}, {30, 50}})
+	is.Add(intInterval{31, 32})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{0, 20}, {30, 50}})
+	is.Add(intInterval{-10, 100})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{-10, 100}})
+	c.Assert(is.Len(), check.Equals, 1)
+}
+
+func (s *intervalSetSuite) TestRemove(c *check.C) {
+	is := NewIntervalSetOrdered[int]()
+	is.Add(intInterval{0, 100})
+	is.Remove(intInterval{10, 20})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{0, 10}, {20, 100}})
+	is.Remove(intInterval{5, 30})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{0, 5}, {30, 100}})
+	is.Remove(intInterval{5, 30})
+	is.Remove(intInterval{40, 40})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{0, 5}, {30, 100}})
+	is.Remove(intInterval{90, 200})
+	is.Remove(intInterval{-10, 1})
+	c.Assert(is.Intervals(), check.DeepEquals, []intInterval{{1, 5}, {30, 90}})
+	is.Remove(intInterval{0, 100})
+	c.Assert(is.Len(), check.Equals, 0)
+}
+
+func (s *intervalSetSuite) TestQueries(c *check.C) {
+	is := NewIntervalSetOrdered[int]()
+	is.Add(intInterval{10, 20})
+	is.Add(intInterval{30, 40})
+
+	c.Assert(is.Contains(10), check.Equals, true)
+	c.Assert(is.Contains(19), check.Equals, true)
+	c.Assert(is.Contains(20), check.Equals, false)
+	c.Assert(is.Contains(5), check.Equals, false)
+	c.Assert(is.Contains(40), check.Equals, false)
+
+	c.Assert(is.Overlaps(intInterval{20, 30}), check.Equals, false)
+	c.Assert(is.Overlaps(intInterval{19, 30}), check.Equals, true)
+	c.Assert(is.Overlaps(intInterval{0, 100}), check.Equals, true)
+	c.Assert(is.Overlaps(intInterval{12, 12}), check.Equals, false)
+	c.Assert(is.Overlaps(intInterval{40, 50}), check.Equals, false)
+
+	c.Assert(is.Gaps(intInterval{0, 50}), check.DeepEquals, []intInterval{{0, 10}, {20, 30}, {40, 50}})
+	c.Assert(is.Gaps(intInterval{15, 35}), check.DeepEquals, []intInterval{{20, 30}})
+	c.Assert(is.Gaps(intInterval{12, 18}), check.IsNil)
+	c.Assert(is.Gaps(intInterval{20, 30}), check.DeepEquals, []intInterval{{20, 30}})
+}
+
+func (s *intervalSetSuite) TestIntervalMap(c *check.C) {
+	m := NewIntervalMapOrdered[int, string]()
+	m.Put(intInterval{0, 10}, "a")
+	m.Put(intInterval{20, 30}, "b")
+	m.Put(intInterval{5, 25}, "c")
+	m.Put(intInterval{1, 1}, "d")
+
+	var ivs []intInterval
+	var vals []string
+	m.ForEach(func(i intInterval, v string) bool {
+		ivs = append(ivs, i)
+		vals = append(vals, v)
+		return true
+	})
+	c.Assert(ivs, check.DeepEquals, []intInterval{{0, 5}, {5, 25}, {25, 30}})
+	c.Assert(vals, check.DeepEquals, []string{"a", "c", "b"})
+
+	v, ok := m.Get(24)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(v, check.Equals, "c")
+	v, ok = m.Get(25)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(v, check.Equals, "b")
+	_, ok = m.Get(30)
+	c.Assert(ok, check.Equals, false)
+
+	m.Put(intInterval{10, 15}, "e")
+	c.Assert(m.Len(), check.Equals, 5)
+	vals = nil
+	m.Range(intInterval{12, 26}, func(i intInterval, v string) bool {
+		vals = append(vals, v)
+		return true
+	})
+	c.Assert(vals, check.DeepEquals, []string{"e", "c", "b"})
+
+	m.Remove(intInterval{0, 12})
+	c.Assert(m.Gaps(intInterval{0, 40}), check.DeepEquals, []intInterval{{0, 12}, {30, 40}})
+	v, _ = m.Get(12)
+	c.Assert(v, check.Equals, "e")
+}
@@ -2,6 +2,7 @@ package gorivets
 
 import (
 	"container/list"
+	"errors"
 	"strconv"
 	"time"
 )
@@ -39,10 +40,12 @@ type (
 
 	Lru struct {
 		list     *list.List
-		elements map[interface{}]*list.Element
+		elements *lruIndex
 		size     int64
 		maxSize  int64
 		callback LruCallback
+		veto     LruVetoCallback
+		maxScan  int
 	}
 
 	element struct {
@@ -53,11 +56,12 @@ type (
 
 	lru_ttl struct {
 		list     *list.List
-		elements map[interface{}]*list.Element
+		elements *lruIndex
 		size     int64
 		maxSize  int64
 		duration time.Duration
 		callback LruCallback
+		clock    ClockF
 	}
 
 	element_ttl struct {
@@ -68,41 +72,169 @@ type (
 	}
 
 	LruCallback func(k, v interface{})
+
+	// LruEntry is an element of LRU snapshot, see LruSnapshot()
+	LruEntry struct {
+		Key   interface{}
+		Value interface{}
+		Size  int64
+	}
+
+	// LruVetoCallback is consulted before an element is evicted, the element
+	// stays in the container if the callback returns false. Only the sized
+	// LRU (see NewVetoLRU) supports it, time-based LRU always drops expired
+	// and exceeding elements.
+	LruVetoCallback func(k, v interface{}) bool
+
+	// ClockF returns current time, time.Now is used by default. It allows to
+	// drive time-based containers by a simulated or test clock.
+	ClockF func() time.Time
+
+	// KeyHashF calculates hash for a key. It is used together with KeyEqualF
+	// to let LRU keep keys which are not comparable (like []byte or structs
+	// holding slices). Equal keys must have equal hashes.
+	KeyHashF func(k interface{}) uint64
+
+	// KeyEqualF returns whether 2 keys are equal.
+	KeyEqualF func(a, b interface{}) bool
+
+	// lruIndex maps keys to the list elements. It uses Go map directly for
+	// comparable keys, or buckets of hashed keys if keyHash is provided
+	lruIndex struct {
+		elements map[interface{}]*list.Element
+		buckets  map[uint64][]hashedEntry
+		keyHash  KeyHashF
+		keyEqual KeyEqualF
+		count    int
+	}
+
+	hashedEntry struct {
+		key interface{}
+		el  *list.Element
+	}
 )
 
 func NewLRU(maxSize int64, callback LruCallback) LRU {
+	return newLru(maxSize, callback, newLruIndex(nil, nil))
+}
+
+// NewHashedLRU creates sized LRU which identifies keys by keyHash and keyEqual
+// functions instead of Go map equality, so non-comparable keys like []byte
+// can be used. Converting a []byte key to interface{} still allocates the
+// slice header once per call, what is half of the allocations needed for
+// string(key) conversion (see BenchmarkHashedLRUGet). The keys are kept by
+// reference, so a key must not be modified after it is added.
+func NewHashedLRU(maxSize int64, keyHash KeyHashF, keyEqual KeyEqualF, callback LruCallback) LRU {
+	assertKeyFuncs(keyHash, keyEqual)
+	return newLru(maxSize, callback, newLruIndex(keyHash, keyEqual))
+}
+
+// NewVetoLRU creates sized LRU which asks veto whether an element chosen for
+// eviction can be dropped. If the element is refused, the next recently used
+// one is considered, but no more than maxScan elements per eviction. If all
+// of them are refused the container stays over maxSize until next Add().
+// The callback is called for the elements which are actually evicted or
+// deleted. There is no time-based version of the container.
+func NewVetoLRU(maxSize int64, maxScan int, veto LruVetoCallback, callback LruCallback) LRU {
+	if veto == nil || maxScan < 1 {
+		panic("veto callback and positive maxScan=" + strconv.Itoa(maxScan) + " should be provided.")
+	}
+	l := newLru(maxSize, callback, newLruIndex(nil, nil))
+	l.veto = veto
+	l.maxScan = maxScan
+	return l
+}
+
+func NewTtlLRU(maxSize int64, duration time.Duration, callback LruCallback) LRU {
+	return newTtlLru(maxSize, duration, callback, newLruIndex(nil, nil))
+}
+
+// NewTtlLRUWithClock creates time-based LRU which takes the current time from
+// the clock provided instead of time.Now
+func NewTtlLRUWithClock(maxSize int64, duration time.Duration, callback LruCallback, clock ClockF) LRU {
+	if clock == nil {
+		panic("clock should be provided.")
+	}
+	l := newTtlLru(maxSize, duration, callback, newLruIndex(nil, nil))
+	l.clock = clock
+	return l
+}
+
+// NewHashedTtlLRU creates time-based LRU which identifies keys by keyHash and
+// keyEqual functions (see NewHashedLRU)
+func NewHashedTtlLRU(maxSize int64, duration time.Duration, keyHash KeyHashF, keyEqual KeyEqualF, callback LruCallback) LRU {
+	assertKeyFuncs(keyHash, keyEqual)
+	return newTtlLru(maxSize, duration, callback, newLruIndex(keyHash, keyEqual))
+}
+
+func newLru(maxSize int64, callback LruCallback, idx *lruIndex) *Lru {
 	if maxSize < 1 {
 		panic("LRU size=" + strconv.FormatInt(maxSize, 10) + " should be positive.")
 	}
 	l := new(Lru)
 	l.list = list.New()
-	l.elements = make(map[interface{}]*list.Element)
+	l.elements = idx
 	l.size = 0
 	l.maxSize = maxSize
 	l.callback = callback
 	return l
 }
 
-func NewTtlLRU(maxSize int64, duration time.Duration, callback LruCallback) LRU {
+func newTtlLru(maxSize int64, duration time.Duration, callback LruCallback, idx *lruIndex) *lru_ttl {
 	if maxSize < 1 {
 		panic("LRU size=" + strconv.FormatInt(maxSize, 10) + " should be positive.")
 	}
 	l := new(lru_ttl)
 	l.list = list.New()
-	l.elements = make(map[interface{}]*list.Element)
+	l.elements = idx
 	l.size = 0
 	l.maxSize = maxSize
 	l.callback = callback
 	l.duration = duration
+	l.clock = time.Now
 	return l
 }
 
+// LruSnapshot returns elements of the LRU created by this package from the
+// least recently used to the most recently used one. The snapshot can be
+// encoded with JSON or gob (the types of keys and values should be
+// registered by gob.Register() in the case), and loaded by RestoreLru().
+func LruSnapshot(l LRU) ([]LruEntry, error) {
+	var lst *list.List
+	switch lru := l.(type) {
+	case *Lru:
+		lst = lru.list
+	case *lru_ttl:
+		lst = lru.list
+	default:
+		return nil, errors.New("Snapshot is not supported for the LRU implementation")
+	}
+	res := make([]LruEntry, 0, lst.Len())
+	for el := lst.Front(); el != nil; el = el.Next() {
+		switch e := el.Value.(type) {
+		case *element:
+			res = append(res, LruEntry{Key: e.key, Value: e.val, Size: e.size})
+		case *element_ttl:
+			res = append(res, LruEntry{Key: e.key, Value: e.val, Size: e.size})
+		}
+	}
+	return res, nil
+}
+
+// RestoreLru adds the snapshot entries to the LRU keeping their order. The
+// entries of time-based LRU get the full duration since the restore.
+func RestoreLru(l LRU, entries []LruEntry) {
+	for _, e := range entries {
+		l.Add(e.Key, e.Value, e.Size)
+	}
+}
+
 // =============================== Lru =======================================
 func (lru *Lru) Add(k, v interface{}, size int64) {
 	lru.Delete(k)
 	e := &element{key: k, val: v, size: size}
 	el := lru.list.PushBack(e)
-	lru.elements[k] = el
+	lru.elements.put(k, el)
 	lru.size += size
 	for lru.size > lru.maxSize && lru.deleteLast() {
 		// left empty intentionally
@@ -110,7 +242,7 @@ func (lru *Lru) Add(k, v interface{}, size int64) {
 }
 
 func (lru *Lru) Get(k interface{}) (interface{}, bool) {
-	if e, ok := lru.elements[k]; ok {
+	if e, ok := lru.elements.get(k); ok {
 		lru.list.MoveToBack(e)
 		return e.Value.(*element).val, true
 	}
@@ -118,7 +250,7 @@ func (lru *Lru) Get(k interface{}) (interface{}, bool) {
 }
 
 func (lru *Lru) Peek(k interface{}) (interface{}, bool) {
-	if e, ok := lru.elements[k]; ok {
+	if e, ok := lru.elements.get(k); ok {
 		return e.Value.(*element).val, true
 	}
 	return nil, false
@@ -129,11 +261,11 @@ func (lru *Lru) Delete(k interface{}) interface{} {
 }
 
 func (lru *Lru) DeleteWithCallback(k interface{}, callback bool) interface{} {
-	el, ok := lru.elements[k]
+	el, ok := lru.elements.get(k)
 	if !ok {
 		return nil
 	}
-	delete(lru.elements, k)
+	lru.elements.remove(k)
 	e := lru.list.Remove(el).(*element)
 	lru.size -= e.size
 	if callback && lru.callback != nil {
@@ -149,12 +281,12 @@ func (lru *Lru) Sweep() {
 // elements
 func (lru *Lru) Clear() {
 	lru.list.Init()
-	lru.elements = make(map[interface{}]*list.Element)
+	lru.elements.clear()
 	lru.size = 0
 }
 
 func (lru *Lru) Len() int {
-	return len(lru.elements)
+	return lru.elements.len()
 }
 
 func (lru *Lru) Size() int64 {
@@ -163,21 +295,27 @@ func (lru *Lru) Size() int64 {
 
 func (lru *Lru) deleteLast() bool {
 	el := lru.list.Front()
-	if el == nil {
-		return false
+	for i := 0; el != nil; i++ {
+		e := el.Value.(*element)
+		if lru.veto == nil || lru.veto(e.key, e.val) {
+			lru.Delete(e.key)
+			return true
+		}
+		if i+1 >= lru.maxScan {
+			break
+		}
+		el = el.Next()
 	}
-	e := el.Value.(*element)
-	lru.Delete(e.key)
-	return true
+	return false
 }
 
 // ============================= lru_ttl =====================================
 func (lru *lru_ttl) Add(k, v interface{}, size int64) {
 	lru.Delete(k)
-	now := time.Now()
+	now := lru.clock()
 	e := &element_ttl{key: k, val: v, size: size, expiredOn: now.Add(lru.duration)}
 	el := lru.list.PushBack(e)
-	lru.elements[k] = el
+	lru.elements.put(k, el)
 	lru.size += size
 	for (lru.size > lru.maxSize || lru.lastExpired(now)) && lru.deleteLast() {
 		// left empty intentionally
@@ -185,9 +323,9 @@ func (lru *lru_ttl) Add(k, v interface{}, size int64) {
 }
 
 func (lru *lru_ttl) Get(k interface{}) (interface{}, bool) {
-	if e, ok := lru.elements[k]; ok {
+	if e, ok := lru.elements.get(k); ok {
 		et := e.Value.(*element_ttl)
-		et.expiredOn = time.Now().Add(lru.duration)
+		et.expiredOn = lru.clock().Add(lru.duration)
 		lru.list.MoveToBack(e)
 		return et.val, true
 	}
@@ -195,7 +333,7 @@ func (lru *lru_ttl) Get(k interface{}) (interface{}, bool) {
 }
 
 func (lru *lru_ttl) Peek(k interface{}) (interface{}, bool) {
-	if e, ok := lru.elements[k]; ok {
+	if e, ok := lru.elements.get(k); ok {
 		et := e.Value.(*element_ttl)
 		return et.val, true
 	}
@@ -215,11 +353,11 @@ func (lru *lru_ttl) delete(k interface{}) interface{} {
 }
 
 func (lru *lru_ttl) deleteWithCallback(k interface{}, callback bool) interface{} {
-	el, ok := lru.elements[k]
+	el, ok := lru.elements.get(k)
 	if !ok {
 		return nil
 	}
-	delete(lru.elements, k)
+	lru.elements.remove(k)
 	e := lru.list.Remove(el).(*element_ttl)
 	lru.size -= e.size
 	if callback && lru.callback != nil {
@@ -236,12 +374,12 @@ func (lru *lru_ttl) Sweep() {
 // elements
 func (lru *lru_ttl) Clear() {
 	lru.list.Init()
-	lru.elements = make(map[interface{}]*list.Element)
+	lru.elements.clear()
 	lru.size = 0
 }
 
 func (lru *lru_ttl) Len() int {
-	return len(lru.elements)
+	return lru.elements.len()
 }
 
 func (lru *lru_ttl) Size() int64 {
@@ -268,8 +406,85 @@ func (lru *lru_ttl) lastExpired(now time.Time) bool {
 }
 
 func (lru *lru_ttl) timeCleanup() {
-	now := time.Now()
+	now := lru.clock()
 	for lru.lastExpired(now) && lru.deleteLast() {
 		// left empty intentionally
 	}
 }
+
+// ============================= lruIndex ====================================
+func assertKeyFuncs(keyHash KeyHashF, keyEqual KeyEqualF) {
+	if keyHash == nil || keyEqual == nil {
+		panic("Both keyHash and keyEqual functions should be provided.")
+	}
+}
+
+func newLruIndex(keyHash KeyHashF, keyEqual KeyEqualF) *lruIndex {
+	li := &lruIndex{keyHash: keyHash, keyEqual: keyEqual}
+	li.clear()
+	return li
+}
+
+func (li *lruIndex) get(k interface{}) (*list.Element, bool) {
+	if li.keyHash == nil {
+		el, ok := li.elements[k]
+		return el, ok
+	}
+	for _, he := range li.buckets[li.keyHash(k)] {
+		if li.keyEqual(k, he.key) {
+			return he.el, true
+		}
+	}
+	return nil, false
+}
+
+// put expects that the key is not in the index yet
+func (li *lruIndex) put(k interface{}, el *list.Element) {
+	if li.keyHash == nil {
+		li.elements[k] = el
+		return
+	}
+	h := li.keyHash(k)
+	li.buckets[h] = append(li.buckets[h], hashedEntry{key: k, el: el})
+	li.count++
+}
+
+func (li *lruIndex) remove(k interface{}) {
+	if li.keyHash == nil {
+		delete(li.elements, k)
+		return
+	}
+	h := li.keyHash(k)
+	b := li.buckets[h]
+	for i, he := range b {
+		if !li.keyEqual(k, he.key) {
+			continue
+		}
+		li.count--
+		if len(b) == 1 {
+			delete(li.buckets, h)
+			return
+		}
+		last := len(b) - 1
+		b[i] = b[last]
+		b[last] = hashedEntry{}
+		li.buckets[h] = b[:last]
+		return
+	}
+}
+
+func (li *lruIndex) clear() {
+	if li.keyHash == nil {
+		li.elements = make(map[interface{}]*list.Element)
+		return
+	}
+	li.buckets = make(map[uint64][]hashedEntry)
+	li.count = 0
+}
+
+func (li *lruIndex) len() int {
+	if li.keyHash == nil {
+		return len(li.elements)
+	}
+	return li.count
+}
@@ -1,8 +1,12 @@
 package gorivets
 
 import (
+	"bytes"
+	"encoding/json"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSimple(t *testing.T) {
@@ -87,3 +91,197 @@ func TestDelete(t *testing.T) {
 	l.Add("d", 23, 250)
 	l.Add("bbb", 23, 10300)
 }
+
+func TestHashedKeys(t *testing.T) {
+	hash := func(k interface{}) uint64 {
+		// poor hash to have collisions
+		return uint64(len(k.([]byte)))
+	}
+	equal := func(a, b interface{}) bool {
+		return bytes.Equal(a.([]byte), b.([]byte))
+	}
+	evicted := 0
+	l := NewHashedLRU(300, hash, equal, func(k, v interface{}) {
+		evicted++
+	})
+	l.Add([]byte("ab"), 1, 100)
+	l.Add([]byte("cd"), 2, 100)
+	l.Add([]byte("abc"), 3, 100)
+	if l.Len() != 3 {
+		t.Fatal("expecting lru len == 3, but len=" + strconv.Itoa(l.Len()))
+	}
+
+	v, ok := l.Get([]byte("cd"))
+	if !ok || v.(int) != 2 {
+		t.Fatal("expecting value 2 for key \"cd\"")
+	}
+	if _, ok := l.Peek([]byte("ef")); ok {
+		t.Fatal("expecting no value for key \"ef\"")
+	}
+
+	l.Add([]byte("ab"), 4, 50)
+	if l.Len() != 3 || l.Size() != 250 || evicted != 1 {
+		t.Fatal("expecting the element to be replaced, len=" + strconv.Itoa(l.Len()))
+	}
+
+	// "abc" is the least recently used now
+	l.Add([]byte("xyz"), 5, 100)
+	if _, ok := l.Peek([]byte("abc")); ok {
+		t.Fatal("expecting \"abc\" to be evicted")
+	}
+	if l.Len() != 3 || l.Size() != 250 || evicted != 2 {
+		t.Fatal("expecting lru len == 3, but len=" + strconv.Itoa(l.Len()))
+	}
+
+	if l.Delete([]byte("cd")).(int) != 2 {
+		t.Fatal("expecting 2 to be deleted")
+	}
+	l.Clear()
+	if l.Len() != 0 || l.Size() != 0 {
+		t.Fatal("expecting empty lru, but len=" + strconv.Itoa(l.Len()))
+	}
+}
+
+func TestHashedTtlKeys(t *testing.T) {
+	type key struct {
+		id   int
+		tags []string
+	}
+	l := NewHashedTtlLRU(1000, time.Minute,
+		func(k interface{}) uint64 { return uint64(k.(key).id) },
+		func(a, b interface{}) bool {
+			ka, kb := a.(key), b.(key)
+			return ka.id == kb.id && strings.Join(ka.tags, ",") == strings.Join(kb.tags, ",")
+		}, nil)
+	l.Add(key{1, []string{"a"}}, "a", 1)
+	l.Add(key{1, []string{"b"}}, "b", 1)
+	if v, ok := l.Get(key{1, []string{"b"}}); !ok || v.(string) != "b" {
+		t.Fatal("expecting value \"b\"")
+	}
+	if l.Len() != 2 {
+		t.Fatal("expecting lru len == 2, but len=" + strconv.Itoa(l.Len()))
+	}
+
+	if CheckPanic(func() { NewHashedLRU(10, nil, nil, nil) }) == nil {
+		t.Fatal("expecting panic when no hash functions provided")
+	}
+}
+
+func TestTtlClock(t *testing.T) {
+	now := time.Unix(1000, 0)
+	evicted := 0
+	l := NewTtlLRUWithClock(1000, time.Minute, func(k, v interface{}) { evicted++ },
+		func() time.Time { return now })
+	l.Add("a", 1, 1)
+	now = now.Add(30 * time.Second)
+	l.Add("b", 2, 1)
+	now = now.Add(31 * time.Second)
+	l.Sweep()
+	if _, ok := l.Peek("a"); ok || evicted != 1 {
+		t.Fatal("expecting \"a\" to be expired")
+	}
+	l.Get("b")
+	now = now.Add(59 * time.Second)
+	l.Sweep()
+	if l.Len() != 1 {
+		t.Fatal("expecting \"b\" is not expired")
+	}
+}
+
+func TestVeto(t *testing.T) {
+	busy := map[string]bool{"a": true, "b": true}
+	evicted := []string{}
+	l := NewVetoLRU(3, 2, func(k, v interface{}) bool {
+		return !busy[k.(string)]
+	}, func(k, v interface{}) {
+		evicted = append(evicted, k.(string))
+	})
+	l.Add("a", 1, 1)
+	l.Add("b", 2, 1)
+	l.Add("c", 3, 1)
+	l.Add("d", 4, 1)
+	if _, ok := l.Peek("a"); !ok || len(evicted) != 0 || l.Len() != 4 {
+		t.Fatal("expecting no eviction, since scan is limited by 2 vetoed elements")
+	}
+
+	busy["b"] = false
+	l.Add("e", 5, 1)
+	if l.Len() != 3 || len(evicted) != 2 || evicted[0] != "b" || evicted[1] != "c" {
+		t.Fatal("expecting \"b\" and \"c\" to be evicted, but evicted=" + strings.Join(evicted, ","))
+	}
+	if _, ok := l.Peek("a"); !ok {
+		t.Fatal("expecting \"a\" to stay in the container")
+	}
+
+	if CheckPanic(func() { NewVetoLRU(10, 0, func(k, v interface{}) bool { return true }, nil) }) == nil {
+		t.Fatal("expecting panic for maxScan=0")
+	}
+}
+
+// The benchmarks compare lookups by []byte key in hashed LRU against
+// converting the key to string for regular LRU
+func BenchmarkHashedLRUGet(b *testing.B) {
+	l := NewHashedLRU(1000,
+		func(k interface{}) uint64 {
+			var h uint64 = 14695981039346656037
+			for _, c := range k.([]byte) {
+				h = (h ^ uint64(c)) * 1099511628211
+			}
+			return h
+		},
+		func(a, b interface{}) bool { return bytes.Equal(a.([]byte), b.([]byte)) }, nil)
+	key := []byte("some-key-of-reasonable-length")
+	l.Add(key, 1, 1)
+	lookup := []byte("some-key-of-reasonable-length")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Get(lookup)
+	}
+}
+
+func BenchmarkStringLRUGet(b *testing.B) {
+	l := NewLRU(1000, nil)
+	l.Add("some-key-of-reasonable-length", 1, 1)
+	lookup := []byte("some-key-of-reasonable-length")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Get(string(lookup))
+	}
+}
+
+func TestLruSnapshot(t *testing.T) {
+	l := NewLRU(100, nil)
+	l.Add("a", "A", 10)
+	l.Add("b", "B", 20)
+	l.Add("c", "C", 30)
+	l.Get("a")
+
+	entries, err := LruSnapshot(l)
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+	var decoded []LruEntry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal("unexpected error ", err)
+	}
+
+	l2 := NewTtlLRU(100, time.Minute, nil)
+	RestoreLru(l2, decoded)
+	restored, _ := LruSnapshot(l2)
+	if len(restored) != 3 || restored[0].Key != "b" || restored[2].Key != "a" || restored[2].Value != "A" ||
+		l2.Size() != 60 {
+		t.Fatal("expecting the order and sizes are restored, but got ", restored)
+	}
+
+	// "b" is the least recently used, so it goes first
+	l2.Add("d", "D", 50)
+	if _, ok := l2.Peek("b"); ok {
+		t.Fatal("expecting \"b\" to be evicted")
+	}
+}
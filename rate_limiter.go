@@ -0,0 +1,210 @@
+package gorivets
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Rate is number of events allowed per period
+	Rate struct {
+		Events int64
+		Period time.Duration
+	}
+
+	// RateLimiter is a registry of token buckets keyed by client (tenant, IP
+	// etc.). Every bucket is refilled with the rate tokens and can hold up to
+	// burst tokens. The buckets are kept in time-based LRU, so a bucket which
+	// is not used for idle timeout is dropped (and comes back full), and the
+	// number of tracked keys never exceeds maxKeys. To be precise, the idle
+	// timeout should not be less than the time needed to refill a bucket.
+	//
+	// Multithread: friendly
+	RateLimiter struct {
+		rate  float64 // tokens per nanosecond
+		burst float64
+		clock ClockF
+		lock  sync.Mutex
+		lru   LRU
+	}
+
+	// Reservation is returned by RateLimiter.Reserve()
+	Reservation struct {
+		rl    *RateLimiter
+		k     interface{}
+		b     *tokenBucket
+		delay time.Duration
+	}
+
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+var cRatePeriods = map[string]time.Duration{
+	"ms":  time.Millisecond,
+	"s":   time.Second,
+	"sec": time.Second,
+	"m":   time.Minute,
+	"min": time.Minute,
+	"h":   time.Hour,
+	"d":   24 * time.Hour,
+}
+
+// ParseRate parses rate string like "100/s", "5k/m" or "10/500ms". The number
+// of events can have scale suffixes (see ParseInt64), the period is one of
+// "ms", "s", "sec", "m", "min", "h", "d", or a duration like "10s".
+func ParseRate(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+	idx := strings.Index(value, "/")
+	if idx < 0 {
+		return Rate{}, errors.New("Rate should be in the form <events>/<period>, but it is \"" + value + "\"")
+	}
+	if strings.TrimSpace(value[:idx]) == "" {
+		return Rate{}, errors.New("Rate events number is not specified in \"" + value + "\"")
+	}
+	ev, err := ParseInt64(value[:idx], 1, math.MaxInt64, 1)
+	if err != nil {
+		return Rate{}, err
+	}
+	ps := strings.ToLower(strings.TrimSpace(value[idx+1:]))
+	period, ok := cRatePeriods[ps]
+	if !ok {
+		period, err = time.ParseDuration(ps)
+		if err != nil {
+			return Rate{}, err
+		}
+	}
+	if period <= 0 {
+		return Rate{}, errors.New("Rate period should be positive, but it is \"" + ps + "\"")
+	}
+	return Rate{Events: ev, Period: period}, nil
+}
+
+func (r Rate) String() string {
+	return strconv.FormatInt(r.Events, 10) + "/" + r.Period.String()
+}
+
+// NewRateLimiter creates new RateLimiter with the rate and burst for every
+// key. It tracks up to maxKeys buckets, and drops the buckets which are idle
+// for idleTimeout.
+func NewRateLimiter(rate Rate, burst int, maxKeys int64, idleTimeout time.Duration) *RateLimiter {
+	return NewRateLimiterWithClock(rate, burst, maxKeys, idleTimeout, time.Now)
+}
+
+// NewRateLimiterWithClock creates RateLimiter (see NewRateLimiter) which takes
+// the current time from the clock provided
+func NewRateLimiterWithClock(rate Rate, burst int, maxKeys int64, idleTimeout time.Duration, clock ClockF) *RateLimiter {
+	if rate.Events <= 0 || rate.Period <= 0 || burst < 1 {
+		panic("Rate=" + rate.String() + " and burst should be positive.")
+	}
+	rl := &RateLimiter{clock: clock, burst: float64(burst)}
+	rl.rate = float64(rate.Events) / float64(rate.Period)
+	rl.lru = NewTtlLRUWithClock(maxKeys, idleTimeout, nil, clock)
+	return rl
+}
+
+// Allow returns whether an event for the key can happen now, it consumes one
+// token from the key bucket if so.
+func (rl *RateLimiter) Allow(k interface{}) bool {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	b := rl.bucket(k)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Reserve consumes one token from the key bucket even though it is not
+// available yet. The returned reservation tells how long the caller should
+// wait before the event can happen.
+func (rl *RateLimiter) Reserve(k interface{}) *Reservation {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	b := rl.bucket(k)
+	b.tokens--
+	r := &Reservation{rl: rl, k: k, b: b}
+	if b.tokens < 0 {
+		r.delay = time.Duration(-b.tokens / rl.rate)
+	}
+	return r
+}
+
+// Wait blocks until an event for the key can happen or the context is done.
+// It returns an error immediately if the context deadline comes before the
+// event is allowed.
+func (rl *RateLimiter) Wait(ctx context.Context, k interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := rl.Reserve(k)
+	if r.delay == 0 {
+		return nil
+	}
+	if dl, ok := ctx.Deadline(); ok && dl.Before(rl.clock().Add(r.delay)) {
+		r.Cancel()
+		return errors.New("Waiting for the rate limiter would exceed the context deadline")
+	}
+
+	t := time.NewTimer(r.delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Len returns number of tracked keys
+func (rl *RateLimiter) Len() int {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	return rl.lru.Len()
+}
+
+// bucket returns the key bucket refilled to the current time, must be called
+// under the lock
+func (rl *RateLimiter) bucket(k interface{}) *tokenBucket {
+	now := rl.clock()
+	if v, ok := rl.lru.Get(k); ok {
+		b := v.(*tokenBucket)
+		b.tokens = math.Min(rl.burst, b.tokens+float64(now.Sub(b.last))*rl.rate)
+		b.last = now
+		return b
+	}
+	b := &tokenBucket{tokens: rl.burst, last: now}
+	rl.lru.Add(k, b, 1)
+	return b
+}
+
+// Delay returns how long the caller should wait before the event
+func (r *Reservation) Delay() time.Duration {
+	return r.delay
+}
+
+// Cancel returns the reserved token back to the bucket
+func (r *Reservation) Cancel() {
+	r.rl.lock.Lock()
+	defer r.rl.lock.Unlock()
+
+	if r.b == nil {
+		return
+	}
+	if v, ok := r.rl.lru.Peek(r.k); ok && v.(*tokenBucket) == r.b {
+		r.b.tokens = math.Min(r.rl.burst, r.b.tokens+1)
+	}
+	r.b = nil
+}
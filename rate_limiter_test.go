@@ -0,0 +1,109 @@
+package gorivets
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+type rateLimiterSuite struct {
+}
+
+var _ = check.Suite(&rateLimiterSuite{})
+
+func (s *rateLimiterSuite) TestParseRate(c *check.C) {
+	r, err := ParseRate("100/s")
+	c.Assert(err, check.IsNil)
+	c.Assert(r, check.Equals, Rate{100, time.Second})
+
+	r, err = ParseRate(" 5k/m ")
+	c.Assert(err, check.IsNil)
+	c.Assert(r, check.Equals, Rate{5000, time.Minute})
+
+	r, err = ParseRate("10/500ms")
+	c.Assert(err, check.IsNil)
+	c.Assert(r, check.Equals, Rate{10, 500 * time.Millisecond})
+	c.Assert(r.String(), check.Equals, "10/500ms")
+
+	_, err = ParseRate("100")
+	c.Assert(err, check.NotNil)
+	_, err = ParseRate("abc/s")
+	c.Assert(err, check.NotNil)
+	_, err = ParseRate("1/week")
+	c.Assert(err, check.NotNil)
+	_, err = ParseRate("1/-1s")
+	c.Assert(err, check.NotNil)
+	_, err = ParseRate("/s")
+	c.Assert(err, check.NotNil)
+	_, err = ParseRate(" /s")
+	c.Assert(err, check.NotNil)
+}
+
+func (s *rateLimiterSuite) TestAllow(c *check.C) {
+	now := time.Now()
+	rl := NewRateLimiterWithClock(Rate{10, time.Second}, 2, 100, time.Minute, func() time.Time { return now })
+
+	c.Assert(rl.Allow("a"), check.Equals, true)
+	c.Assert(rl.Allow("a"), check.Equals, true)
+	c.Assert(rl.Allow("a"), check.Equals, false)
+	c.Assert(rl.Allow("b"), check.Equals, true)
+
+	now = now.Add(100 * time.Millisecond)
+	c.Assert(rl.Allow("a"), check.Equals, true)
+	c.Assert(rl.Allow("a"), check.Equals, false)
+
+	// never more than burst
+	now = now.Add(time.Second)
+	c.Assert(rl.Allow("a"), check.Equals, true)
+	c.Assert(rl.Allow("a"), check.Equals, true)
+	c.Assert(rl.Allow("a"), check.Equals, false)
+}
+
+func (s *rateLimiterSuite) TestBoundedKeys(c *check.C) {
+	now := time.Now()
+	rl := NewRateLimiterWithClock(Rate{1, time.Second}, 1, 2, time.Minute, func() time.Time { return now })
+	rl.Allow("a")
+	rl.Allow("b")
+	rl.Allow("c")
+	c.Assert(rl.Len(), check.Equals, 2)
+
+	now = now.Add(2 * time.Minute)
+	rl.Allow("d")
+	c.Assert(rl.Len(), check.Equals, 1)
+}
+
+func (s *rateLimiterSuite) TestReserve(c *check.C) {
+	now := time.Now()
+	rl := NewRateLimiterWithClock(Rate{10, time.Second}, 1, 100, time.Minute, func() time.Time { return now })
+
+	c.Assert(rl.Reserve("a").Delay(), check.Equals, time.Duration(0))
+	r := rl.Reserve("a")
+	c.Assert(r.Delay(), check.Equals, 100*time.Millisecond)
+	c.Assert(rl.Reserve("a").Delay(), check.Equals, 200*time.Millisecond)
+
+	r.Cancel()
+	r.Cancel()
+	c.Assert(rl.Reserve("a").Delay(), check.Equals, 200*time.Millisecond)
+}
+
+func (s *rateLimiterSuite) TestWait(c *check.C) {
+	rl := NewRateLimiter(Rate{100, time.Second}, 1, 100, time.Minute)
+	start := time.Now()
+	c.Assert(rl.Wait(context.Background(), "a"), check.IsNil)
+	c.Assert(rl.Wait(context.Background(), "a"), check.IsNil)
+	c.Assert(time.Since(start) >= 9*time.Millisecond, check.Equals, true)
+
+	// the clock doesn't move, so the token is never refilled
+	now := time.Now()
+	rl = NewRateLimiterWithClock(Rate{1, time.Hour}, 1, 100, time.Minute, func() time.Time { return now })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(rl.Wait(ctx, "a"), check.Equals, context.Canceled)
+	c.Assert(rl.Allow("a"), check.Equals, true)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	c.Assert(rl.Wait(ctx, "a"), check.NotNil)
+	c.Assert(rl.Reserve("a").Delay(), check.Equals, time.Hour)
+}
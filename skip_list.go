@@ -0,0 +1,201 @@
+package gorivets
+
+import (
+	"cmp"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// SkipList is an indexable skip list, which keeps elements ordered like
+// SortedSlice does in SortedMultiset mode, and has similar methods. Unlike
+// SortedSlice it doesn't shift elements on insertions and deletions, so Add,
+// Delete, DeleteAt and At take O(log n) (expected) time. It makes sense to
+// use it for big collections with frequent modifications, see the benchmarks
+// for the crossover point.
+//
+// Every link of the list stores its width - number of elements it jumps
+// over, what allows positional access.
+type SkipList[T any] struct {
+	compF  func(a, b T) int
+	head   *skipNode[T]
+	length int
+	rnd    uint64
+}
+
+type skipNode[T any] struct {
+	val   T
+	next  []*skipNode[T]
+	width []int
+}
+
+const cSkipListMaxLevel = 32
+
+// NewSkipList creates an empty SkipList ordered by compF
+func NewSkipList[T any](compF func(a, b T) int) *SkipList[T] {
+	sl := &SkipList[T]{compF: compF, rnd: uint64(time.Now().UnixNano()) | 1}
+	sl.head = &skipNode[T]{next: make([]*skipNode[T], cSkipListMaxLevel), width: make([]int, cSkipListMaxLevel)}
+	for i := range sl.head.width {
+		sl.head.width[i] = 1
+	}
+	return sl
+}
+
+// NewSkipListOrdered creates an empty SkipList with natural ordering of T
+func NewSkipListOrdered[T cmp.Ordered]() *SkipList[T] {
+	return NewSkipList(cmp.Compare[T])
+}
+
+func (sl *SkipList[T]) Len() int {
+	return sl.length
+}
+
+// Add inserts val after all elements equal to it, returns its index
+func (sl *SkipList[T]) Add(val T) (int, error) {
+	if any(val) == nil {
+		return -1, errors.New("val=nil cannot be added to the collection.")
+	}
+
+	var update [cSkipListMaxLevel]*skipNode[T]
+	var rank [cSkipListMaxLevel]int
+	x, pos := sl.head, 0
+	for i := cSkipListMaxLevel - 1; i >= 0; i-- {
+		for x.next[i] != nil && sl.compF(x.next[i].val, val) <= 0 {
+			pos += x.width[i]
+			x = x.next[i]
+		}
+		update[i], rank[i] = x, pos
+	}
+
+	lvl := sl.randomLevel()
+	n := &skipNode[T]{val: val, next: make([]*skipNode[T], lvl), width: make([]int, lvl)}
+	for i := 0; i < lvl; i++ {
+		u := update[i]
+		n.next[i] = u.next[i]
+		u.next[i] = n
+		n.width[i] = u.width[i] - (pos - rank[i])
+		u.width[i] = pos - rank[i] + 1
+	}
+	for i := lvl; i < cSkipListMaxLevel; i++ {
+		update[i].width[i]++
+	}
+	sl.length++
+	return pos, nil
+}
+
+// At returns element by its index
+func (sl *SkipList[T]) At(idx int) T {
+	return sl.nodeAt(idx).val
+}
+
+// Find returns index of the first element equal to val. If there is no such
+// element, -(idx+1) is returned, where idx is the insertion position.
+func (sl *SkipList[T]) Find(val T) (int, bool) {
+	x, pos := sl.head, 0
+	for i := cSkipListMaxLevel - 1; i >= 0; i-- {
+		for x.next[i] != nil && sl.compF(x.next[i].val, val) < 0 {
+			pos += x.width[i]
+			x = x.next[i]
+		}
+	}
+	if n := x.next[0]; n != nil && sl.compF(val, n.val) == 0 {
+		return pos, true
+	}
+	return -(pos + 1), false
+}
+
+// Delete removes the first element equal to val
+func (sl *SkipList[T]) Delete(val T) bool {
+	idx, ok := sl.Find(val)
+	if ok {
+		sl.DeleteAt(idx)
+	}
+	return ok
+}
+
+// DeleteAt removes element by its index and returns it
+func (sl *SkipList[T]) DeleteAt(idx int) T {
+	if idx < 0 || idx >= sl.length {
+		panic("SkipList index=" + strconv.Itoa(idx) + " is out of range")
+	}
+	var update [cSkipListMaxLevel]*skipNode[T]
+	x, pos := sl.head, 0
+	for i := cSkipListMaxLevel - 1; i >= 0; i-- {
+		for x.next[i] != nil && pos+x.width[i] <= idx {
+			pos += x.width[i]
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	n := update[0].next[0]
+	for i := 0; i < cSkipListMaxLevel; i++ {
+		u := update[i]
+		if u.next[i] == n {
+			u.width[i] += n.width[i] - 1
+			u.next[i] = n.next[i]
+		} else {
+			u.width[i]--
+		}
+	}
+	sl.length--
+	return n.val
+}
+
+// GetInsertPos returns position where val would be inserted, which is after
+// all elements equal to val
+func (sl *SkipList[T]) GetInsertPos(val T) int {
+	x, pos := sl.head, 0
+	for i := cSkipListMaxLevel - 1; i >= 0; i-- {
+		for x.next[i] != nil && sl.compF(x.next[i].val, val) <= 0 {
+			pos += x.width[i]
+			x = x.next[i]
+		}
+	}
+	return pos
+}
+
+func (sl *SkipList[T]) Copy() []T {
+	res := make([]T, 0, sl.length)
+	for n := sl.head.next[0]; n != nil; n = n.next[0] {
+		res = append(res, n.val)
+	}
+	return res
+}
+
+func (sl *SkipList[T]) Clear() {
+	for i := range sl.head.next {
+		sl.head.next[i] = nil
+		sl.head.width[i] = 1
+	}
+	sl.length = 0
+}
+
+// nodeAt returns node by index, the head has index -1
+func (sl *SkipList[T]) nodeAt(idx int) *skipNode[T] {
+	if idx < 0 || idx >= sl.length {
+		panic("SkipList index=" + strconv.Itoa(idx) + " is out of range")
+	}
+	x, pos := sl.head, 0
+	for i := cSkipListMaxLevel - 1; i >= 0; i-- {
+		for x.next[i] != nil && pos+x.width[i] <= idx+1 {
+			pos += x.width[i]
+			x = x.next[i]
+		}
+	}
+	return x
+}
+
+// randomLevel returns level of a new node, every next level has probability
+// 1/4 (xorshift64 is used as the random source)
+func (sl *SkipList[T]) randomLevel() int {
+	sl.rnd ^= sl.rnd << 13
+	sl.rnd ^= sl.rnd >> 7
+	sl.rnd ^= sl.rnd << 17
+	lvl, r := 1, sl.rnd
+	for lvl < cSkipListMaxLevel && r&3 == 0 {
+		lvl++
+		r >>= 2
+	}
+	return lvl
+}
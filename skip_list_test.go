@@ -0,0 +1,109 @@
+package gorivets
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+type skipListSuite struct {
+}
+
+var _ = check.Suite(&skipListSuite{})
+
+func (s *skipListSuite) TestSimple(c *check.C) {
+	sl := NewSkipList(compModeParam)
+	for i, k := range []int{2, 1, 2, 3, 2} {
+		idx, err := sl.Add(modeParam{k, i})
+		c.Assert(err, check.IsNil)
+		c.Assert(sl.At(idx), check.Equals, modeParam{k, i})
+	}
+	c.Assert(sl.Len(), check.Equals, 5)
+	c.Assert(sl.Copy(), check.DeepEquals, []modeParam{{1, 1}, {2, 0}, {2, 2}, {2, 4}, {3, 3}})
+
+	idx, ok := sl.Find(modeParam{k: 2})
+	c.Assert(ok, check.Equals, true)
+	c.Assert(idx, check.Equals, 1)
+	idx, ok = sl.Find(modeParam{k: 0})
+	c.Assert(ok, check.Equals, false)
+	c.Assert(idx, check.Equals, -1)
+	c.Assert(sl.GetInsertPos(modeParam{k: 2}), check.Equals, 4)
+
+	c.Assert(sl.Delete(modeParam{k: 2}), check.Equals, true)
+	c.Assert(sl.Delete(modeParam{k: 5}), check.Equals, false)
+	c.Assert(sl.DeleteAt(3), check.Equals, modeParam{3, 3})
+	c.Assert(sl.Copy(), check.DeepEquals, []modeParam{{1, 1}, {2, 2}, {2, 4}})
+
+	c.Assert(CheckPanic(func() { sl.At(3) }), check.NotNil)
+	c.Assert(CheckPanic(func() { sl.DeleteAt(-1) }), check.NotNil)
+
+	sl.Clear()
+	c.Assert(sl.Len(), check.Equals, 0)
+	sl.Add(modeParam{1, 1})
+	c.Assert(sl.At(0), check.Equals, modeParam{1, 1})
+
+	var ns *SkipList[interface{}] = NewSkipList[interface{}](ccf)
+	_, err := ns.Add(nil)
+	c.Assert(err, check.NotNil)
+}
+
+func (s *skipListSuite) TestRandom(c *check.C) {
+	sl := NewSkipListOrdered[int]()
+	ss, _ := NewSortedSliceOrdered[int](1)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(500)
+		switch r.Intn(4) {
+		case 0:
+			if ss.Len() > 0 {
+				idx := r.Intn(ss.Len())
+				c.Assert(sl.DeleteAt(idx), check.Equals, ss.DeleteAt(idx))
+			}
+		case 1:
+			c.Assert(sl.Delete(v), check.Equals, ss.Delete(v))
+		default:
+			i1, _ := sl.Add(v)
+			i2, _ := ss.Add(v)
+			c.Assert(i1, check.Equals, i2)
+		}
+		i1, ok1 := sl.Find(v)
+		i2, ok2 := ss.Find(v)
+		c.Assert(i1, check.Equals, i2)
+		c.Assert(ok1, check.Equals, ok2)
+		if ss.Len() > 0 {
+			idx := r.Intn(ss.Len())
+			c.Assert(sl.At(idx), check.Equals, ss.At(idx))
+		}
+	}
+	c.Assert(sl.Copy(), check.DeepEquals, ss.Copy())
+}
+
+// The benchmarks insert and delete random values in a collection of the
+// given size. SkipList outperforms SortedSlice somewhere between 10^3 and
+// 10^4 elements.
+func benchmarkOrdered(b *testing.B, size int, add func(v int), deleteAt func(idx int)) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < size; i++ {
+		add(r.Int())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		add(r.Int())
+		deleteAt(r.Intn(size))
+	}
+}
+
+func BenchmarkSortedVsSkipList(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run("SortedSlice-"+strconv.Itoa(size), func(b *testing.B) {
+			ss, _ := NewSortedSliceOrdered[int](size + 1)
+			benchmarkOrdered(b, size, func(v int) { ss.Add(v) }, func(idx int) { ss.DeleteAt(idx) })
+		})
+		b.Run("SkipList-"+strconv.Itoa(size), func(b *testing.B) {
+			sl := NewSkipListOrdered[int]()
+			benchmarkOrdered(b, size, func(v int) { sl.Add(v) }, func(idx int) { sl.DeleteAt(idx) })
+		})
+	}
+}
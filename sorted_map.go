@@ -0,0 +1,173 @@
+package gorivets
+
+import (
+	"cmp"
+	"iter"
+)
+
+// SortedMap keeps key-value pairs ordered by keys. It is built on top of
+// SortedSlice, so Get and navigation take O(log n), but Put and Delete of
+// new keys take O(n) because of shifting.
+type SortedMap[K, V any] struct {
+	ss *SortedSlice[mapEntry[K, V]]
+}
+
+type mapEntry[K, V any] struct {
+	key K
+	val V
+}
+
+// NewSortedMap creates an empty SortedMap with keys ordered by compF
+func NewSortedMap[K, V any](compF func(a, b K) int) *SortedMap[K, V] {
+	entryCompF := func(a, b mapEntry[K, V]) int {
+		return compF(a.key, b.key)
+	}
+	ss, _ := NewSortedSliceWithMode(entryCompF, SortedSetReplace, 10)
+	return &SortedMap[K, V]{ss: ss}
+}
+
+// NewSortedMapOrdered creates an empty SortedMap with natural ordering of
+// keys
+func NewSortedMapOrdered[K cmp.Ordered, V any]() *SortedMap[K, V] {
+	return NewSortedMap[K, V](cmp.Compare[K])
+}
+
+// NewComparableSortedMap creates an empty SortedMap with Comparable keys
+func NewComparableSortedMap[V any]() *SortedMap[Comparable, V] {
+	return NewSortedMap[Comparable, V](func(a, b Comparable) int {
+		return a.Compare(b)
+	})
+}
+
+func (sm *SortedMap[K, V]) Len() int {
+	return sm.ss.Len()
+}
+
+// Put associates v with k. Returns the previous value and true if the key
+// was already in the map.
+func (sm *SortedMap[K, V]) Put(k K, v V) (V, bool) {
+	e := mapEntry[K, V]{key: k, val: v}
+	idx := sm.ss.binarySearch(e)
+	if idx >= 0 {
+		old := sm.ss.data[idx].val
+		sm.ss.data[idx].val = v
+		return old, true
+	}
+
+	sm.ss.insertAt(-(idx + 1), e)
+	var zero V
+	return zero, false
+}
+
+func (sm *SortedMap[K, V]) Get(k K) (V, bool) {
+	if idx, ok := sm.ss.Find(mapEntry[K, V]{key: k}); ok {
+		return sm.ss.data[idx].val, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (sm *SortedMap[K, V]) ContainsKey(k K) bool {
+	_, ok := sm.ss.Find(mapEntry[K, V]{key: k})
+	return ok
+}
+
+// Delete removes the key from the map, returns its value and true if the key
+// was there
+func (sm *SortedMap[K, V]) Delete(k K) (V, bool) {
+	if idx, ok := sm.ss.Find(mapEntry[K, V]{key: k}); ok {
+		return sm.ss.DeleteAt(idx).val, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Keys returns all keys in ascending order
+func (sm *SortedMap[K, V]) Keys() []K {
+	res := make([]K, len(sm.ss.data))
+	for i, e := range sm.ss.data {
+		res[i] = e.key
+	}
+	return res
+}
+
+// Values returns all values in ascending order of their keys
+func (sm *SortedMap[K, V]) Values() []V {
+	res := make([]V, len(sm.ss.data))
+	for i, e := range sm.ss.data {
+		res[i] = e.val
+	}
+	return res
+}
+
+// ForEach calls f for every key-value pair in ascending order of keys until
+// f returns false. The map must not be modified by f.
+func (sm *SortedMap[K, V]) ForEach(f func(k K, v V) bool) {
+	sm.forEach(0, len(sm.ss.data), f)
+}
+
+// All returns iterator over key-value pairs in ascending order of keys
+func (sm *SortedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		sm.ForEach(yield)
+	}
+}
+
+// Range calls f for key-value pairs with keys between from and to in
+// ascending order until f returns false. The bounds are included if
+// inclusiveFrom and inclusiveTo are true.
+func (sm *SortedMap[K, V]) Range(from, to K, inclusiveFrom, inclusiveTo bool, f func(k K, v V) bool) {
+	start, end := sm.ss.Range(mapEntry[K, V]{key: from}, mapEntry[K, V]{key: to}, inclusiveFrom, inclusiveTo)
+	sm.forEach(start, end, f)
+}
+
+// First returns the pair with the smallest key
+func (sm *SortedMap[K, V]) First() (K, V, bool) {
+	return sm.entry(sm.ss.checkIdx(0))
+}
+
+// Last returns the pair with the greatest key
+func (sm *SortedMap[K, V]) Last() (K, V, bool) {
+	return sm.entry(sm.ss.checkIdx(len(sm.ss.data) - 1))
+}
+
+// Floor returns the pair with the greatest key which is less than or equal
+// to k
+func (sm *SortedMap[K, V]) Floor(k K) (K, V, bool) {
+	return sm.entry(sm.ss.Floor(mapEntry[K, V]{key: k}))
+}
+
+// Ceiling returns the pair with the smallest key which is greater than or
+// equal to k
+func (sm *SortedMap[K, V]) Ceiling(k K) (K, V, bool) {
+	return sm.entry(sm.ss.Ceiling(mapEntry[K, V]{key: k}))
+}
+
+// Lower returns the pair with the greatest key which is strictly less than k
+func (sm *SortedMap[K, V]) Lower(k K) (K, V, bool) {
+	return sm.entry(sm.ss.Lower(mapEntry[K, V]{key: k}))
+}
+
+// Higher returns the pair with the smallest key which is strictly greater
+// than k
+func (sm *SortedMap[K, V]) Higher(k K) (K, V, bool) {
+	return sm.entry(sm.ss.Higher(mapEntry[K, V]{key: k}))
+}
+
+func (sm *SortedMap[K, V]) entry(idx int, ok bool) (K, V, bool) {
+	if !ok {
+		var k K
+		var v V
+		return k, v, false
+	}
+	e := sm.ss.data[idx]
+	return e.key, e.val, true
+}
+
+func (sm *SortedMap[K, V]) forEach(start, end int, f func(k K, v V) bool) {
+	for i := start; i < end; i++ {
+		if !f(sm.ss.data[i].key, sm.ss.data[i].val) {
+			return
+		}
+	}
+}
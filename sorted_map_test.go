@@ -0,0 +1,91 @@
+package gorivets
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type sortedMapSuite struct {
+}
+
+var _ = check.Suite(&sortedMapSuite{})
+
+func (s *sortedMapSuite) TestPutGetDelete(c *check.C) {
+	sm := NewSortedMapOrdered[string, int]()
+	for i, k := range []string{"c", "a", "b"} {
+		_, ok := sm.Put(k, i)
+		c.Assert(ok, check.Equals, false)
+	}
+	old, ok := sm.Put("a", 10)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(old, check.Equals, 1)
+	c.Assert(sm.Len(), check.Equals, 3)
+	c.Assert(sm.Keys(), check.DeepEquals, []string{"a", "b", "c"})
+	c.Assert(sm.Values(), check.DeepEquals, []int{10, 2, 0})
+
+	v, ok := sm.Get("b")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(v, check.Equals, 2)
+	_, ok = sm.Get("d")
+	c.Assert(ok, check.Equals, false)
+	c.Assert(sm.ContainsKey("c"), check.Equals, true)
+	c.Assert(sm.ContainsKey("0"), check.Equals, false)
+
+	v, ok = sm.Delete("c")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(v, check.Equals, 0)
+	_, ok = sm.Delete("c")
+	c.Assert(ok, check.Equals, false)
+	c.Assert(sm.Keys(), check.DeepEquals, []string{"a", "b"})
+}
+
+func (s *sortedMapSuite) TestNavigation(c *check.C) {
+	sm := NewSortedMapOrdered[int, string]()
+	_, _, ok := sm.First()
+	c.Assert(ok, check.Equals, false)
+	for _, k := range []int{10, 30, 20, 40} {
+		sm.Put(k, "v")
+	}
+
+	key := func(k int, v string, ok bool) int {
+		if !ok {
+			return -1
+		}
+		return k
+	}
+	c.Assert(key(sm.First()), check.Equals, 10)
+	c.Assert(key(sm.Last()), check.Equals, 40)
+	c.Assert(key(sm.Floor(25)), check.Equals, 20)
+	c.Assert(key(sm.Floor(20)), check.Equals, 20)
+	c.Assert(key(sm.Floor(5)), check.Equals, -1)
+	c.Assert(key(sm.Ceiling(25)), check.Equals, 30)
+	c.Assert(key(sm.Ceiling(45)), check.Equals, -1)
+	c.Assert(key(sm.Lower(20)), check.Equals, 10)
+	c.Assert(key(sm.Higher(20)), check.Equals, 30)
+
+	var keys []int
+	collect := func(k int, v string) bool {
+		keys = append(keys, k)
+		return true
+	}
+	sm.Range(20, 40, true, false, collect)
+	c.Assert(keys, check.DeepEquals, []int{20, 30})
+	keys = nil
+	sm.Range(20, 40, false, true, collect)
+	c.Assert(keys, check.DeepEquals, []int{30, 40})
+	keys = nil
+	sm.ForEach(func(k int, v string) bool {
+		keys = append(keys, k)
+		return k < 30
+	})
+	c.Assert(keys, check.DeepEquals, []int{10, 20, 30})
+}
+
+func (s *sortedMapSuite) TestComparable(c *check.C) {
+	sm := NewComparableSortedMap[string]()
+	sm.Put(Int64(2), "two")
+	sm.Put(Int64(1), "one")
+	v, ok := sm.Get(Int64(1))
+	c.Assert(ok, check.Equals, true)
+	c.Assert(v, check.Equals, "one")
+	c.Assert(sm.Values(), check.DeepEquals, []string{"one", "two"})
+}
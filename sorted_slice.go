@@ -1,71 +1,280 @@
 package gorivets
 
 import (
+	"cmp"
 	"errors"
+	"slices"
 	"strconv"
 )
 
-type SortedSlice struct {
-	compF CompareF
-	data  []interface{}
+// SortedSlice keeps elements of type T ordered by the comparator provided.
+// The comparator returns a value(val): val < 0 if a < b, val == 0 if a == b,
+// and val > 0 if a > b
+type SortedSlice[T any] struct {
+	compF func(a, b T) int
+	// name of the comparator in the registry, see RegisterComparator()
+	compName string
+	mode     SortedSliceMode
+	data     []T
 }
 
-func NewSortedSliceByComp(compF CompareF, initialCapacity int) (*SortedSlice, error) {
+// SortedSliceMode defines how SortedSlice treats equal elements
+type SortedSliceMode int
+
+const (
+	// Equal elements are allowed and kept in the insertion order, Find and
+	// Delete deal with the first of them. This is the default mode.
+	SortedMultiset SortedSliceMode = iota
+	// Only one of equal elements is kept, Add returns ErrAlreadyPresent and
+	// doesn't change the collection if an equal element is there
+	SortedSetKeep
+	// Only one of equal elements is kept, Add replaces the equal element
+	SortedSetReplace
+)
+
+// ErrAlreadyPresent is returned by SortedSlice.Add in SortedSetKeep mode
+var ErrAlreadyPresent = errors.New("An equal element is already present in the collection.")
+
+// NewSortedSliceFunc creates an empty SortedSlice ordered by compF
+func NewSortedSliceFunc[T any](compF func(a, b T) int, initialCapacity int) (*SortedSlice[T], error) {
+	return NewSortedSliceWithMode(compF, SortedMultiset, initialCapacity)
+}
+
+// NewSortedSliceWithMode creates an empty SortedSlice ordered by compF, which
+// treats equal elements according to the mode provided
+func NewSortedSliceWithMode[T any](compF func(a, b T) int, mode SortedSliceMode, initialCapacity int) (*SortedSlice[T], error) {
 	if initialCapacity <= 0 {
 		return nil, errors.New("initialCapacity=" + strconv.Itoa(initialCapacity) + " should not be negative integer.")
 	}
-	return &SortedSlice{compF: compF, data: make([]interface{}, 0, initialCapacity)}, nil
+	if mode < SortedMultiset || mode > SortedSetReplace {
+		return nil, errors.New("Unknown SortedSlice mode=" + strconv.Itoa(int(mode)))
+	}
+	return &SortedSlice[T]{compF: compF, mode: mode, data: make([]T, 0, initialCapacity)}, nil
 }
 
-func NewSortedSlice(initialCapacity int) (*SortedSlice, error) {
-	return NewSortedSliceByComp(ccf, initialCapacity)
+// NewSortedSliceOrdered creates an empty SortedSlice with natural ordering
+// of T
+func NewSortedSliceOrdered[T cmp.Ordered](initialCapacity int) (*SortedSlice[T], error) {
+	return NewSortedSliceFunc(cmp.Compare[T], initialCapacity)
 }
 
-func NewSortedSliceByParams(data ...interface{}) (*SortedSlice, error) {
-	return NewSortedSliceByCompAndParams(ccf, data...)
-}
-
-func NewSortedSliceByCompAndParams(compF CompareF, data ...interface{}) (*SortedSlice, error) {
+// NewSortedSliceFuncAndParams creates SortedSlice ordered by compF and
+// containing the data provided
+func NewSortedSliceFuncAndParams[T any](compF func(a, b T) int, data ...T) (*SortedSlice[T], error) {
 	if data == nil {
 		return nil, errors.New("Cannot create SortedSlice from data=nil")
 	}
-	ss := &SortedSlice{compF: compF, data: make([]interface{}, 0, len(data))}
-	for _, val := range data {
-		ss.Add(val)
+	ss := &SortedSlice[T]{compF: compF, data: make([]T, 0, len(data))}
+	ss.addAll(data, true)
+	return ss, nil
+}
+
+// NewSortedSliceFromSorted creates SortedSlice from data, which is already
+// sorted by compF. The order is only validated, so it takes O(n). An error is
+// returned if data is not sorted, or it has equal elements while the mode
+// is not SortedMultiset. The data is copied.
+func NewSortedSliceFromSorted[T any](compF func(a, b T) int, mode SortedSliceMode, data []T) (*SortedSlice[T], error) {
+	ss, err := NewSortedSliceWithMode(compF, mode, Max(len(data), 1))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSorted(compF, mode, data); err != nil {
+		return nil, err
 	}
+	ss.data = append(ss.data, data...)
 	return ss, nil
 }
 
-func (ss *SortedSlice) Len() int {
+func checkSorted[T any](compF func(a, b T) int, mode SortedSliceMode, data []T) error {
+	for i, val := range data {
+		if any(val) == nil {
+			return errors.New("val=nil at " + strconv.Itoa(i) + " cannot be added to the collection.")
+		}
+		if i == 0 {
+			continue
+		}
+		c := compF(data[i-1], val)
+		if c > 0 || (c == 0 && mode != SortedMultiset) {
+			return errors.New("The data is not sorted at index " + strconv.Itoa(i))
+		}
+	}
+	return nil
+}
+
+// NewSortedSliceByComp creates SortedSlice of interface{} values, compatible
+// with the non-generic version of the collection
+func NewSortedSliceByComp(compF CompareF, initialCapacity int) (*SortedSlice[interface{}], error) {
+	return NewSortedSliceFunc[interface{}](compF, initialCapacity)
+}
+
+// NewSortedSlice creates SortedSlice of Comparable values
+func NewSortedSlice(initialCapacity int) (*SortedSlice[interface{}], error) {
+	return NewSortedSliceByComp(ccf, initialCapacity)
+}
+
+func NewSortedSliceByParams(data ...interface{}) (*SortedSlice[interface{}], error) {
+	return NewSortedSliceByCompAndParams(ccf, data...)
+}
+
+func NewSortedSliceByCompAndParams(compF CompareF, data ...interface{}) (*SortedSlice[interface{}], error) {
+	return NewSortedSliceFuncAndParams[interface{}](compF, data...)
+}
+
+func (ss *SortedSlice[T]) Len() int {
 	return len(ss.data)
 }
 
-func (ss *SortedSlice) Add(val interface{}) (int, error) {
-	if val == nil {
+func (ss *SortedSlice[T]) Mode() SortedSliceMode {
+	return ss.mode
+}
+
+// Add inserts val into the collection and returns its index. Equal elements
+// are handled according to the collection mode, the index of the equal
+// element is returned with ErrAlreadyPresent in SortedSetKeep mode.
+func (ss *SortedSlice[T]) Add(val T) (int, error) {
+	if any(val) == nil {
 		return -1, errors.New("val=nil cannot be added to the collection.")
 	}
 
+	if ss.mode != SortedMultiset {
+		if idx, ok := ss.Find(val); ok {
+			if ss.mode == SortedSetKeep {
+				return idx, ErrAlreadyPresent
+			}
+			ss.data[idx] = val
+			return idx, nil
+		}
+	}
+
 	idx := ss.GetInsertPos(val)
+	ss.insertAt(idx, val)
+	return idx, nil
+}
+
+func (ss *SortedSlice[T]) insertAt(idx int, val T) {
 	if idx >= ss.Len() {
 		ss.data = append(ss.data, val)
-	} else {
-		ss.data = append(ss.data, nil)
-		copy(ss.data[idx+1:], ss.data[idx:])
-		ss.data[idx] = val
+		return
 	}
-	return idx, nil
+	var zero T
+	ss.data = append(ss.data, zero)
+	copy(ss.data[idx+1:], ss.data[idx:])
+	ss.data[idx] = val
+}
+
+// AddAll adds all the values to the collection. The values are sorted and
+// merged with the collection elements in one pass, what takes O(n + m*log(m)),
+// instead of O(n*m) for adding them one by one. Equal elements are handled
+// like Add does, but no error is returned for them in SortedSetKeep mode.
+// Returns number of the new elements in the collection.
+func (ss *SortedSlice[T]) AddAll(values ...T) (int, error) {
+	for _, val := range values {
+		if any(val) == nil {
+			return 0, errors.New("val=nil cannot be added to the collection.")
+		}
+	}
+	return ss.addAll(values, false), nil
+}
+
+func (ss *SortedSlice[T]) addAll(values []T, skipNil bool) int {
+	batch := make([]T, 0, len(values))
+	for _, val := range values {
+		if !skipNil || any(val) != nil {
+			batch = append(batch, val)
+		}
+	}
+	slices.SortStableFunc(batch, ss.compF)
+	if ss.mode != SortedMultiset {
+		batch = ss.dedup(batch)
+	}
+
+	before := len(ss.data)
+	if before == 0 {
+		ss.data = append(ss.data, batch...)
+		return len(batch)
+	}
+
+	merged := make([]T, 0, before+len(batch))
+	i, j := 0, 0
+	for i < before && j < len(batch) {
+		c := ss.compF(batch[j], ss.data[i])
+		switch {
+		case c < 0:
+			merged = append(merged, batch[j])
+			j++
+		case c > 0 || ss.mode == SortedMultiset:
+			// existing elements go before the equal new ones
+			merged = append(merged, ss.data[i])
+			i++
+		case ss.mode == SortedSetKeep:
+			merged = append(merged, ss.data[i])
+			i++
+			j++
+		default:
+			merged = append(merged, batch[j])
+			i++
+			j++
+		}
+	}
+	merged = append(merged, ss.data[i:]...)
+	merged = append(merged, batch[j:]...)
+	ss.data = merged
+	return len(merged) - before
+}
+
+// dedup leaves one of equal elements in the sorted batch: the first one for
+// SortedSetKeep mode and the last one for SortedSetReplace
+func (ss *SortedSlice[T]) dedup(batch []T) []T {
+	res := batch[:0]
+	for _, val := range batch {
+		if len(res) > 0 && ss.compF(res[len(res)-1], val) == 0 {
+			if ss.mode == SortedSetReplace {
+				res[len(res)-1] = val
+			}
+			continue
+		}
+		res = append(res, val)
+	}
+	return res
 }
 
-func (ss *SortedSlice) At(idx int) interface{} {
+func (ss *SortedSlice[T]) At(idx int) T {
 	return ss.data[idx]
 }
 
-func (ss *SortedSlice) Find(val interface{}) (int, bool) {
+// Find returns index of the first element equal to val. If there is no such
+// element a negative value is returned, the insertion position of val
+// is -(idx+1) in the case.
+func (ss *SortedSlice[T]) Find(val T) (int, bool) {
 	idx := ss.binarySearch(val)
 	return idx, idx >= 0
 }
 
-func (ss *SortedSlice) Delete(val interface{}) bool {
+// FirstIndex returns index of the first element equal to val
+func (ss *SortedSlice[T]) FirstIndex(val T) (int, bool) {
+	idx := ss.binarySearch(val)
+	if idx < 0 {
+		return -1, false
+	}
+	return idx, true
+}
+
+// LastIndex returns index of the last element equal to val
+func (ss *SortedSlice[T]) LastIndex(val T) (int, bool) {
+	idx := ss.upperBound(val) - 1
+	if idx < 0 || ss.compF(val, ss.data[idx]) != 0 {
+		return -1, false
+	}
+	return idx, true
+}
+
+// Count returns number of elements equal to val
+func (ss *SortedSlice[T]) Count(val T) int {
+	return ss.upperBound(val) - ss.lowerBound(val)
+}
+
+// Delete removes the first element equal to val
+func (ss *SortedSlice[T]) Delete(val T) bool {
 	idx := ss.binarySearch(val)
 	if idx < 0 {
 		return false
@@ -74,19 +283,178 @@ func (ss *SortedSlice) Delete(val interface{}) bool {
 	return true
 }
 
-func (ss *SortedSlice) DeleteAt(idx int) interface{} {
+// DeleteAll removes all elements equal to val, returns number of the removed
+// elements
+func (ss *SortedSlice[T]) DeleteAll(val T) int {
+	l := ss.lowerBound(val)
+	h := ss.upperBound(val)
+	if l == h {
+		return 0
+	}
+	ss.data = slices.Delete(ss.data, l, h)
+	return h - l
+}
+
+// DeleteAt removes element by its index and returns it. The released slot
+// at the end of the underlying array is zeroed, so the element can be
+// garbage collected.
+func (ss *SortedSlice[T]) DeleteAt(idx int) T {
 	result := ss.data[idx]
-	ss.data = append(ss.data[:idx], ss.data[idx+1:]...)
+	ss.data = slices.Delete(ss.data, idx, idx+1)
 	return result
 }
 
-func (ss *SortedSlice) Copy() []interface{} {
-	c := make([]interface{}, len(ss.data))
+// Fix moves the element at idx to its correct position after the element was
+// changed in a way affecting its ordering (like heap.Fix does). It takes
+// O(log n) comparisons, and only elements between the old and the new
+// positions are shifted. Returns the new index of the element. Uniqueness of
+// the element is not checked in set modes, use Update() for that.
+func (ss *SortedSlice[T]) Fix(idx int) int {
+	val := ss.data[idx]
+	switch {
+	case idx > 0 && ss.compF(val, ss.data[idx-1]) < 0:
+		pos := ss.upperBoundIn(val, 0, idx)
+		copy(ss.data[pos+1:idx+1], ss.data[pos:idx])
+		ss.data[pos] = val
+		return pos
+	case idx < len(ss.data)-1 && ss.compF(val, ss.data[idx+1]) > 0:
+		pos := ss.upperBoundIn(val, idx+1, len(ss.data)) - 1
+		copy(ss.data[idx:pos], ss.data[idx+1:pos+1])
+		ss.data[pos] = val
+		return pos
+	}
+	return idx
+}
+
+// Update replaces the element at idx by val and moves it to its correct
+// position, see Fix(). In set modes, if there is another element equal to
+// val, the collection is not changed and ErrAlreadyPresent is returned with
+// the equal element index in SortedSetKeep mode, like Add() does; in
+// SortedSetReplace mode the equal element is replaced by val and the element
+// at idx is removed. Returns the new index of val (or of the equal element).
+func (ss *SortedSlice[T]) Update(idx int, val T) (int, error) {
+	if any(val) == nil {
+		return -1, errors.New("val=nil cannot be added to the collection.")
+	}
+	if ss.mode != SortedMultiset {
+		if eq, ok := ss.Find(val); ok && eq != idx {
+			if ss.mode == SortedSetKeep {
+				return eq, ErrAlreadyPresent
+			}
+			ss.data[eq] = val
+			ss.DeleteAt(idx)
+			if eq > idx {
+				eq--
+			}
+			return eq, nil
+		}
+	}
+	ss.data[idx] = val
+	return ss.Fix(idx), nil
+}
+
+// DeleteRange removes elements with indexes in [from, to), returns number of
+// the removed elements
+func (ss *SortedSlice[T]) DeleteRange(from, to int) int {
+	ss.data = slices.Delete(ss.data, from, to)
+	return to - from
+}
+
+// DeleteIf removes all elements for which pred returns true in one pass,
+// returns number of the removed elements
+func (ss *SortedSlice[T]) DeleteIf(pred func(val T) bool) int {
+	before := len(ss.data)
+	ss.data = slices.DeleteFunc(ss.data, pred)
+	return before - len(ss.data)
+}
+
+// TruncateBefore removes all elements which are less than val, returns
+// number of the removed elements
+func (ss *SortedSlice[T]) TruncateBefore(val T) int {
+	return ss.DeleteRange(0, ss.lowerBound(val))
+}
+
+// TruncateAfter removes all elements which are greater than val, returns
+// number of the removed elements
+func (ss *SortedSlice[T]) TruncateAfter(val T) int {
+	return ss.DeleteRange(ss.upperBound(val), len(ss.data))
+}
+
+func (ss *SortedSlice[T]) Copy() []T {
+	c := make([]T, len(ss.data))
 	copy(c, ss.data)
 	return c
 }
 
-func (ss *SortedSlice) GetInsertPos(val interface{}) int {
+func (ss *SortedSlice[T]) clone() *SortedSlice[T] {
+	return &SortedSlice[T]{compF: ss.compF, compName: ss.compName, mode: ss.mode, data: ss.Copy()}
+}
+
+// First returns the smallest element of the collection
+func (ss *SortedSlice[T]) First() (T, bool) {
+	if len(ss.data) == 0 {
+		var zero T
+		return zero, false
+	}
+	return ss.data[0], true
+}
+
+// Last returns the greatest element of the collection
+func (ss *SortedSlice[T]) Last() (T, bool) {
+	if len(ss.data) == 0 {
+		var zero T
+		return zero, false
+	}
+	return ss.data[len(ss.data)-1], true
+}
+
+// Floor returns index of the greatest element which is less than or equal
+// to val
+func (ss *SortedSlice[T]) Floor(val T) (int, bool) {
+	return ss.checkIdx(ss.upperBound(val) - 1)
+}
+
+// Ceiling returns index of the smallest element which is greater than or
+// equal to val
+func (ss *SortedSlice[T]) Ceiling(val T) (int, bool) {
+	return ss.checkIdx(ss.lowerBound(val))
+}
+
+// Lower returns index of the greatest element which is strictly less than val
+func (ss *SortedSlice[T]) Lower(val T) (int, bool) {
+	return ss.checkIdx(ss.lowerBound(val) - 1)
+}
+
+// Higher returns index of the smallest element which is strictly greater than
+// val
+func (ss *SortedSlice[T]) Higher(val T) (int, bool) {
+	return ss.checkIdx(ss.upperBound(val))
+}
+
+// Range returns span [start, end) of indexes of the elements between from and
+// to. The bounds are included into the span if inclusiveFrom and inclusiveTo
+// are true. The span is empty (start == end) if there are no such elements.
+func (ss *SortedSlice[T]) Range(from, to T, inclusiveFrom, inclusiveTo bool) (int, int) {
+	var start, end int
+	if inclusiveFrom {
+		start = ss.lowerBound(from)
+	} else {
+		start = ss.upperBound(from)
+	}
+	if inclusiveTo {
+		end = ss.upperBound(to)
+	} else {
+		end = ss.lowerBound(to)
+	}
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+// GetInsertPos returns position where val would be inserted, which is after
+// all elements equal to val
+func (ss *SortedSlice[T]) GetInsertPos(val T) int {
 	len := len(ss.data)
 	if len == 0 {
 		return 0
@@ -96,28 +464,101 @@ func (ss *SortedSlice) GetInsertPos(val interface{}) int {
 		return len
 	}
 
-	idx := ss.binarySearch(val)
+	return ss.upperBound(val)
+}
+
+func (ss *SortedSlice[T]) checkIdx(idx int) (int, bool) {
+	if idx < 0 || idx >= len(ss.data) {
+		return -1, false
+	}
+	return idx, true
+}
+
+// binarySearch returns index of the first element equal to val, or -(idx+1)
+// where idx is the position where val would be inserted
+func (ss *SortedSlice[T]) binarySearch(val T) int {
+	return binarySearchBy(ss, val, ss.compF)
+}
+
+// lowerBound returns index of the first element which is not less than val
+func (ss *SortedSlice[T]) lowerBound(val T) int {
+	return LowerBoundBy(ss, val, ss.compF)
+}
+
+// upperBound returns index of the first element which is greater than val
+func (ss *SortedSlice[T]) upperBound(val T) int {
+	return UpperBoundBy(ss, val, ss.compF)
+}
+
+// upperBoundIn returns index of the first element in [l, h) which is greater
+// than val
+func (ss *SortedSlice[T]) upperBoundIn(val T, l, h int) int {
+	for l < h {
+		m := int(uint(l+h) >> 1)
+		if ss.compF(val, ss.data[m]) >= 0 {
+			l = m + 1
+		} else {
+			h = m
+		}
+	}
+	return l
+}
+
+// FindBy returns index of the first element, for which compF(key, elem)
+// returns 0. compF compares a key with an element and must be consistent
+// with the collection ordering, it allows to search by a part of the element
+// (ID for instance) without constructing a complete element. If there is no
+// such element, -(idx+1) is returned, where idx is the insertion position.
+func FindBy[T, K any](ss *SortedSlice[T], key K, compF func(key K, elem T) int) (int, bool) {
+	idx := binarySearchBy(ss, key, compF)
+	return idx, idx >= 0
+}
+
+// DeleteBy removes the first element, for which compF(key, elem) returns 0,
+// see FindBy
+func DeleteBy[T, K any](ss *SortedSlice[T], key K, compF func(key K, elem T) int) bool {
+	idx := binarySearchBy(ss, key, compF)
 	if idx < 0 {
-		return -(idx + 1)
+		return false
 	}
-	return idx
+	ss.DeleteAt(idx)
+	return true
 }
 
-func (ss *SortedSlice) binarySearch(val interface{}) int {
-	h := len(ss.data) - 1
-	l := 0
-	for l <= h {
-		m := (l + h) >> 1
-		vm := ss.data[m]
-		c := ss.compF(val, vm)
-		switch {
-		case c < 0:
-			h = m - 1
-		case c > 0:
+// LowerBoundBy returns index of the first element, which is not less than
+// key, see FindBy
+func LowerBoundBy[T, K any](ss *SortedSlice[T], key K, compF func(key K, elem T) int) int {
+	l, h := 0, len(ss.data)
+	for l < h {
+		m := int(uint(l+h) >> 1)
+		if compF(key, ss.data[m]) > 0 {
 			l = m + 1
-		default:
-			return m
+		} else {
+			h = m
 		}
 	}
+	return l
+}
+
+// UpperBoundBy returns index of the first element, which is greater than key,
+// see FindBy
+func UpperBoundBy[T, K any](ss *SortedSlice[T], key K, compF func(key K, elem T) int) int {
+	l, h := 0, len(ss.data)
+	for l < h {
+		m := int(uint(l+h) >> 1)
+		if compF(key, ss.data[m]) >= 0 {
+			l = m + 1
+		} else {
+			h = m
+		}
+	}
+	return l
+}
+
+func binarySearchBy[T, K any](ss *SortedSlice[T], key K, compF func(key K, elem T) int) int {
+	l := LowerBoundBy(ss, key, compF)
+	if l < len(ss.data) && compF(key, ss.data[l]) == 0 {
+		return l
+	}
 	return -(l + 1)
 }
@@ -0,0 +1,141 @@
+package gorivets
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// sortedSliceState is the serialized form of SortedSlice. The comparator
+// cannot be serialized, so only its name in the registry is kept.
+type sortedSliceState[T any] struct {
+	Comparator string          `json:"comparator,omitempty"`
+	Mode       SortedSliceMode `json:"mode"`
+	Data       []T             `json:"data"`
+}
+
+var (
+	cmpRegistryLock sync.RWMutex
+	cmpRegistry     = make(map[string]interface{})
+)
+
+// RegisterComparator makes compF available by the name for creating and
+// decoding SortedSlices, see NewSortedSliceByName(). It panics if the name
+// is already registered.
+func RegisterComparator[T any](name string, compF func(a, b T) int) {
+	cmpRegistryLock.Lock()
+	defer cmpRegistryLock.Unlock()
+
+	if _, ok := cmpRegistry[name]; ok || compF == nil {
+		panic("Comparator name=\"" + name + "\" is already registered or compF is nil")
+	}
+	cmpRegistry[name] = compF
+}
+
+func lookupComparator[T any](name string) (func(a, b T) int, error) {
+	cmpRegistryLock.RLock()
+	defer cmpRegistryLock.RUnlock()
+
+	f, ok := cmpRegistry[name]
+	if !ok {
+		return nil, errors.New("Comparator name=\"" + name + "\" is not registered")
+	}
+	compF, ok := f.(func(a, b T) int)
+	if !ok {
+		return nil, errors.New("Comparator name=\"" + name + "\" is registered for another type")
+	}
+	return compF, nil
+}
+
+// NewSortedSliceByName creates an empty SortedSlice ordered by the comparator
+// registered by RegisterComparator(). The name is serialized with the
+// collection, so it can be decoded without providing the comparator.
+func NewSortedSliceByName[T any](name string, mode SortedSliceMode, initialCapacity int) (*SortedSlice[T], error) {
+	compF, err := lookupComparator[T](name)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := NewSortedSliceWithMode(compF, mode, initialCapacity)
+	if err != nil {
+		return nil, err
+	}
+	ss.compName = name
+	return ss, nil
+}
+
+// MarshalJSON encodes the collection as {"comparator": name, "mode": mode,
+// "data": [elements]}
+func (ss *SortedSlice[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ss.state())
+}
+
+// UnmarshalJSON decodes the collection encoded by MarshalJSON(). If ss was
+// created with a comparator, the comparator is used, otherwise it is looked
+// up in the registry by the encoded name. The encoded name is ignored for
+// a comparator not created by NewSortedSliceByName(), and ss stays unnamed.
+// The order of the decoded elements is validated.
+func (ss *SortedSlice[T]) UnmarshalJSON(b []byte) error {
+	var st sortedSliceState[T]
+	if err := json.Unmarshal(b, &st); err != nil {
+		return err
+	}
+	return ss.restore(&st)
+}
+
+// MarshalBinary encodes the collection with gob, see MarshalJSON()
+func (ss *SortedSlice[T]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ss.state()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the collection encoded by MarshalBinary(), see
+// UnmarshalJSON()
+func (ss *SortedSlice[T]) UnmarshalBinary(b []byte) error {
+	var st sortedSliceState[T]
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&st); err != nil {
+		return err
+	}
+	return ss.restore(&st)
+}
+
+func (ss *SortedSlice[T]) state() *sortedSliceState[T] {
+	return &sortedSliceState[T]{Comparator: ss.compName, Mode: ss.mode, Data: ss.data}
+}
+
+func (ss *SortedSlice[T]) restore(st *sortedSliceState[T]) error {
+	if st.Mode < SortedMultiset || st.Mode > SortedSetReplace {
+		return errors.New("Unknown SortedSlice mode=" + strconv.Itoa(int(st.Mode)))
+	}
+	compF, name := ss.compF, ss.compName
+	if name != "" && st.Comparator != "" && st.Comparator != name {
+		return errors.New("Cannot decode SortedSlice ordered by comparator name=\"" + st.Comparator +
+			"\" into the collection ordered by \"" + name + "\"")
+	}
+	// the name is taken from the payload only with the comparator, an unnamed
+	// comparator of ss keeps the collection unnamed
+	if compF == nil {
+		if st.Comparator == "" {
+			return errors.New("Cannot decode SortedSlice: no comparator name is encoded, and no comparator is provided")
+		}
+		var err error
+		if compF, err = lookupComparator[T](st.Comparator); err != nil {
+			return err
+		}
+		name = st.Comparator
+	}
+	if err := checkSorted(compF, st.Mode, st.Data); err != nil {
+		return err
+	}
+	ss.compF, ss.compName, ss.mode = compF, name, st.Mode
+	ss.data = st.Data
+	if ss.data == nil {
+		ss.data = []T{}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package gorivets
+
+import (
+	"cmp"
+	"encoding/json"
+
+	"gopkg.in/check.v1"
+)
+
+type sortedSliceCodecSuite struct {
+}
+
+var _ = check.Suite(&sortedSliceCodecSuite{})
+
+func init() {
+	RegisterComparator("codecTestDesc", func(a, b int) int { return b - a })
+}
+
+func (s *sortedSliceCodecSuite) TestRegistry(c *check.C) {
+	c.Assert(CheckPanic(func() { RegisterComparator("codecTestDesc", CompareInt) }), check.NotNil)
+	_, err := NewSortedSliceByName[int]("codecTestUnknown", SortedMultiset, 1)
+	c.Assert(err, check.NotNil)
+	_, err = NewSortedSliceByName[string]("codecTestDesc", SortedMultiset, 1)
+	c.Assert(err, check.NotNil)
+
+	ss, err := NewSortedSliceByName[int]("codecTestDesc", SortedMultiset, 1)
+	c.Assert(err, check.IsNil)
+	ss.AddAll(1, 3, 2)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{3, 2, 1})
+}
+
+func (s *sortedSliceCodecSuite) TestJSON(c *check.C) {
+	ss, _ := NewSortedSliceByName[int]("codecTestDesc", SortedSetKeep, 1)
+	ss.AddAll(1, 3, 2)
+	b, err := json.Marshal(ss)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(b), check.Equals, `{"comparator":"codecTestDesc","mode":1,"data":[3,2,1]}`)
+
+	var ss2 SortedSlice[int]
+	c.Assert(json.Unmarshal(b, &ss2), check.IsNil)
+	c.Assert(ss2.Copy(), check.DeepEquals, []int{3, 2, 1})
+	c.Assert(ss2.Mode(), check.Equals, SortedSetKeep)
+	_, err = ss2.Add(2)
+	c.Assert(err, check.Equals, ErrAlreadyPresent)
+	ss2.Add(0)
+	c.Assert(ss2.Copy(), check.DeepEquals, []int{3, 2, 1, 0})
+
+	// comparator provided at decode time
+	ss3 := newIntSortedSlice()
+	c.Assert(json.Unmarshal([]byte(`{"mode":0,"data":[1,1,2]}`), ss3), check.IsNil)
+	c.Assert(ss3.Copy(), check.DeepEquals, []int{1, 1, 2})
+
+	var ss4 SortedSlice[int]
+	c.Assert(json.Unmarshal([]byte(`{"mode":0,"data":[1,2]}`), &ss4), check.NotNil)
+	c.Assert(json.Unmarshal([]byte(`{"comparator":"codecTestDesc","data":[1,2]}`), &ss4), check.NotNil)
+	c.Assert(json.Unmarshal([]byte(`{"comparator":"codecTestDesc","mode":1,"data":[2,2]}`), &ss4), check.NotNil)
+	c.Assert(json.Unmarshal([]byte(`{"comparator":"codecTestDesc","mode":5,"data":[]}`), &ss4), check.NotNil)
+	c.Assert(json.Unmarshal([]byte(`{"comparator":"codecTestDesc","mode":0}`), &ss4), check.IsNil)
+	c.Assert(ss4.Len(), check.Equals, 0)
+
+	// the encoded name is not taken by an unnamed comparator
+	ss5, _ := NewSortedSliceFunc(cmp.Compare[int], 1)
+	c.Assert(json.Unmarshal([]byte(`{"comparator":"codecTestDesc","data":[1,2,3]}`), ss5), check.IsNil)
+	b, err = json.Marshal(ss5)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(b), check.Equals, `{"mode":0,"data":[1,2,3]}`)
+	ss6, _ := NewSortedSliceFunc(cmp.Compare[int], 1)
+	c.Assert(json.Unmarshal(b, ss6), check.IsNil)
+	c.Assert(ss6.Copy(), check.DeepEquals, []int{1, 2, 3})
+
+	// the payload is ordered by another comparator
+	c.Assert(json.Unmarshal([]byte(`{"comparator":"codecTestOther","mode":0,"data":[1,2]}`), ss), check.NotNil)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{3, 2, 1})
+}
+
+func (s *sortedSliceCodecSuite) TestBinary(c *check.C) {
+	ss, _ := NewSortedSliceByName[int]("codecTestDesc", SortedMultiset, 1)
+	ss.AddAll(5, 1, 5, 3)
+	b, err := ss.MarshalBinary()
+	c.Assert(err, check.IsNil)
+
+	var ss2 SortedSlice[int]
+	c.Assert(ss2.UnmarshalBinary(b), check.IsNil)
+	c.Assert(ss2.Copy(), check.DeepEquals, []int{5, 5, 3, 1})
+	c.Assert(ss2.UnmarshalBinary(b[:len(b)-2]), check.NotNil)
+
+	ss3 := newIntSortedSlice()
+	c.Assert(ss3.UnmarshalBinary(b), check.NotNil)
+}
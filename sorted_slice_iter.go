@@ -0,0 +1,138 @@
+package gorivets
+
+import (
+	"iter"
+)
+
+// SortedSliceIterator walks through SortedSlice elements in both directions
+// without copying them. The iterator is positioned between elements, Next()
+// and Prev() move it over an element and make the element current:
+//
+//	for it := ss.Iterator(); it.Next(); {
+//		v := it.Value()
+//		...
+//	}
+//
+// The collection must not be modified while iterating, except by the
+// iterator Remove().
+type SortedSliceIterator[T any] struct {
+	ss  *SortedSlice[T]
+	pos int // index of the element returned by Next()
+	cur int // index of the current element, -1 if there is no one
+}
+
+// Iterator returns iterator positioned before the first element
+func (ss *SortedSlice[T]) Iterator() *SortedSliceIterator[T] {
+	return ss.IteratorAt(0)
+}
+
+// IteratorAt returns iterator positioned before the element with index idx,
+// so Next() returns the element and Prev() returns the previous one. idx is
+// limited by [0..Len()]
+func (ss *SortedSlice[T]) IteratorAt(idx int) *SortedSliceIterator[T] {
+	it := &SortedSliceIterator[T]{ss: ss}
+	it.SeekIdx(idx)
+	return it
+}
+
+// Seek positions the iterator before the first element which is not less
+// than val
+func (it *SortedSliceIterator[T]) Seek(val T) {
+	it.SeekIdx(it.ss.lowerBound(val))
+}
+
+// SeekIdx positions the iterator before the element with index idx
+func (it *SortedSliceIterator[T]) SeekIdx(idx int) {
+	it.pos = Min(Max(idx, 0), it.ss.Len())
+	it.cur = -1
+}
+
+// Next moves to the next element, returns false if there is no one
+func (it *SortedSliceIterator[T]) Next() bool {
+	if it.pos >= it.ss.Len() {
+		it.cur = -1
+		return false
+	}
+	it.cur = it.pos
+	it.pos++
+	return true
+}
+
+// Prev moves to the previous element, returns false if there is no one
+func (it *SortedSliceIterator[T]) Prev() bool {
+	if it.pos <= 0 {
+		it.cur = -1
+		return false
+	}
+	it.pos--
+	it.cur = it.pos
+	return true
+}
+
+// Value returns the current element
+func (it *SortedSliceIterator[T]) Value() T {
+	return it.ss.data[it.checkCur()]
+}
+
+// Index returns index of the current element
+func (it *SortedSliceIterator[T]) Index() int {
+	return it.checkCur()
+}
+
+// Remove deletes the current element from the collection and returns it. The
+// iterator stays between the neighbours of the removed element, so the
+// iteration can be continued in any direction.
+func (it *SortedSliceIterator[T]) Remove() T {
+	val := it.ss.DeleteAt(it.checkCur())
+	if it.cur < it.pos {
+		it.pos--
+	}
+	it.cur = -1
+	return val
+}
+
+func (it *SortedSliceIterator[T]) checkCur() int {
+	if it.cur < 0 {
+		panic("The iterator has no current element, Next() or Prev() should be called.")
+	}
+	return it.cur
+}
+
+// All returns iterator over indexes and elements in ascending order
+func (ss *SortedSlice[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := 0; i < len(ss.data); i++ {
+			if !yield(i, ss.data[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns iterator over indexes and elements in descending order
+func (ss *SortedSlice[T]) Backward() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := len(ss.data) - 1; i >= 0; i-- {
+			if !yield(i, ss.data[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns iterator over elements in ascending order
+func (ss *SortedSlice[T]) Values() iter.Seq[T] {
+	return ss.ValuesFrom(0)
+}
+
+// ValuesFrom returns iterator over elements in ascending order starting from
+// the element with index idx
+func (ss *SortedSlice[T]) ValuesFrom(idx int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := Max(idx, 0); i < len(ss.data); i++ {
+			if !yield(ss.data[i]) {
+				return
+			}
+		}
+	}
+}
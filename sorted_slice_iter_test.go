@@ -0,0 +1,102 @@
+package gorivets
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type sortedSliceIterSuite struct {
+}
+
+var _ = check.Suite(&sortedSliceIterSuite{})
+
+func (s *sortedSliceIterSuite) TestIterator(c *check.C) {
+	ss := newIntSortedSlice(1, 3, 5, 7)
+	var res []int
+	for it := ss.Iterator(); it.Next(); {
+		res = append(res, it.Value())
+	}
+	c.Assert(res, check.DeepEquals, []int{1, 3, 5, 7})
+
+	res = nil
+	for it := ss.IteratorAt(ss.Len()); it.Prev(); {
+		res = append(res, it.Value())
+	}
+	c.Assert(res, check.DeepEquals, []int{7, 5, 3, 1})
+
+	it := ss.Iterator()
+	it.Seek(4)
+	c.Assert(it.Next(), check.Equals, true)
+	c.Assert(it.Value(), check.Equals, 5)
+	c.Assert(it.Index(), check.Equals, 2)
+	c.Assert(it.Prev(), check.Equals, true)
+	c.Assert(it.Value(), check.Equals, 5)
+	c.Assert(it.Prev(), check.Equals, true)
+	c.Assert(it.Value(), check.Equals, 3)
+
+	it.SeekIdx(10)
+	c.Assert(it.Next(), check.Equals, false)
+	c.Assert(CheckPanic(func() { it.Value() }), check.NotNil)
+	it.SeekIdx(-1)
+	c.Assert(it.Prev(), check.Equals, false)
+	c.Assert(it.Next(), check.Equals, true)
+	c.Assert(it.Value(), check.Equals, 1)
+}
+
+func (s *sortedSliceIterSuite) TestRemove(c *check.C) {
+	ss := newIntSortedSlice(1, 2, 3, 4, 5, 6)
+	var removed []int
+	for it := ss.Iterator(); it.Next(); {
+		if it.Value()%2 == 0 {
+			removed = append(removed, it.Remove())
+		}
+	}
+	c.Assert(removed, check.DeepEquals, []int{2, 4, 6})
+	c.Assert(ss.Copy(), check.DeepEquals, []int{1, 3, 5})
+
+	it := ss.IteratorAt(ss.Len())
+	c.Assert(it.Prev(), check.Equals, true)
+	c.Assert(it.Prev(), check.Equals, true)
+	c.Assert(it.Remove(), check.Equals, 3)
+	c.Assert(CheckPanic(func() { it.Remove() }), check.NotNil)
+	c.Assert(it.Prev(), check.Equals, true)
+	c.Assert(it.Value(), check.Equals, 1)
+	c.Assert(it.Next(), check.Equals, true)
+	c.Assert(it.Next(), check.Equals, true)
+	c.Assert(it.Value(), check.Equals, 5)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{1, 5})
+}
+
+func (s *sortedSliceIterSuite) TestRangeOverFunc(c *check.C) {
+	ss := newIntSortedSlice(3, 1, 2)
+	var res []int
+	for i, v := range ss.All() {
+		c.Assert(ss.At(i), check.Equals, v)
+		res = append(res, v)
+	}
+	c.Assert(res, check.DeepEquals, []int{1, 2, 3})
+
+	res = nil
+	for _, v := range ss.Backward() {
+		res = append(res, v)
+		if v == 2 {
+			break
+		}
+	}
+	c.Assert(res, check.DeepEquals, []int{3, 2})
+
+	res = nil
+	idx, _ := ss.Ceiling(2)
+	for v := range ss.ValuesFrom(idx) {
+		res = append(res, v)
+	}
+	c.Assert(res, check.DeepEquals, []int{2, 3})
+
+	sm := NewSortedMapOrdered[int, string]()
+	sm.Put(2, "b")
+	sm.Put(1, "a")
+	var keys []int
+	for k := range sm.All() {
+		keys = append(keys, k)
+	}
+	c.Assert(keys, check.DeepEquals, []int{1, 2})
+}
@@ -0,0 +1,119 @@
+package gorivets
+
+// Set operations between 2 SortedSlices. The collections are expected to be
+// ordered by the same comparator, the comparator of the receiver is used.
+// Every operation takes linear time. For multisets equal elements are paired
+// one to one, so the union keeps the maximum number of equal elements of the
+// both collections, the intersection keeps the minimum, and the difference
+// keeps the rest.
+//
+// The result collection has the receiver comparator and mode. The ...Func
+// versions stream the result to f instead, and stop when f returns false.
+
+const (
+	mergeOnlyA = 1 << iota
+	mergeOnlyB
+	mergeBoth
+)
+
+// Union returns elements which are in either of the collections
+func (ss *SortedSlice[T]) Union(other *SortedSlice[T]) *SortedSlice[T] {
+	return ss.collect(other, mergeOnlyA|mergeOnlyB|mergeBoth)
+}
+
+func (ss *SortedSlice[T]) UnionFunc(other *SortedSlice[T], f func(val T) bool) {
+	ss.merge(other, mergeOnlyA|mergeOnlyB|mergeBoth, f)
+}
+
+// Intersection returns elements which are in the both collections
+func (ss *SortedSlice[T]) Intersection(other *SortedSlice[T]) *SortedSlice[T] {
+	return ss.collect(other, mergeBoth)
+}
+
+func (ss *SortedSlice[T]) IntersectionFunc(other *SortedSlice[T], f func(val T) bool) {
+	ss.merge(other, mergeBoth, f)
+}
+
+// Difference returns elements of the collection, which are not in other
+func (ss *SortedSlice[T]) Difference(other *SortedSlice[T]) *SortedSlice[T] {
+	return ss.collect(other, mergeOnlyA)
+}
+
+func (ss *SortedSlice[T]) DifferenceFunc(other *SortedSlice[T], f func(val T) bool) {
+	ss.merge(other, mergeOnlyA, f)
+}
+
+// SymmetricDifference returns elements which are in one of the collections,
+// but not in the both
+func (ss *SortedSlice[T]) SymmetricDifference(other *SortedSlice[T]) *SortedSlice[T] {
+	return ss.collect(other, mergeOnlyA|mergeOnlyB)
+}
+
+func (ss *SortedSlice[T]) SymmetricDifferenceFunc(other *SortedSlice[T], f func(val T) bool) {
+	ss.merge(other, mergeOnlyA|mergeOnlyB, f)
+}
+
+// IsSubset returns whether all elements of the collection are in other
+func (ss *SortedSlice[T]) IsSubset(other *SortedSlice[T]) bool {
+	if ss.Len() > other.Len() {
+		return false
+	}
+	res := true
+	ss.merge(other, mergeOnlyA, func(val T) bool {
+		res = false
+		return false
+	})
+	return res
+}
+
+// Equal returns whether the both collections have the same elements
+func (ss *SortedSlice[T]) Equal(other *SortedSlice[T]) bool {
+	return ss.Len() == other.Len() && ss.IsSubset(other)
+}
+
+func (ss *SortedSlice[T]) collect(other *SortedSlice[T], ops int) *SortedSlice[T] {
+	res := &SortedSlice[T]{compF: ss.compF, compName: ss.compName, mode: ss.mode}
+	ss.merge(other, ops, func(val T) bool {
+		res.data = append(res.data, val)
+		return true
+	})
+	return res
+}
+
+// merge walks the both collections and calls f for the elements selected by
+// ops. The element of the receiver is used if the both have it.
+func (ss *SortedSlice[T]) merge(other *SortedSlice[T], ops int, f func(val T) bool) {
+	a, b := ss.data, other.data
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		c := ss.compF(a[i], b[j])
+		switch {
+		case c < 0:
+			if ops&mergeOnlyA != 0 && !f(a[i]) {
+				return
+			}
+			i++
+		case c > 0:
+			if ops&mergeOnlyB != 0 && !f(b[j]) {
+				return
+			}
+			j++
+		default:
+			if ops&mergeBoth != 0 && !f(a[i]) {
+				return
+			}
+			i++
+			j++
+		}
+	}
+	for ; i < len(a) && ops&mergeOnlyA != 0; i++ {
+		if !f(a[i]) {
+			return
+		}
+	}
+	for ; j < len(b) && ops&mergeOnlyB != 0; j++ {
+		if !f(b[j]) {
+			return
+		}
+	}
+}
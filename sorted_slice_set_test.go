@@ -0,0 +1,81 @@
+package gorivets
+
+import (
+	"cmp"
+
+	"gopkg.in/check.v1"
+)
+
+type sortedSliceSetSuite struct {
+}
+
+var _ = check.Suite(&sortedSliceSetSuite{})
+
+func newIntSortedSlice(vals ...int) *SortedSlice[int] {
+	ss, _ := NewSortedSliceFunc(cmp.Compare[int], 1)
+	ss.AddAll(vals...)
+	return ss
+}
+
+func (s *sortedSliceSetSuite) TestOperations(c *check.C) {
+	a := newIntSortedSlice(1, 2, 2, 3, 5)
+	b := newIntSortedSlice(2, 3, 3, 4)
+
+	c.Assert(a.Union(b).Copy(), check.DeepEquals, []int{1, 2, 2, 3, 3, 4, 5})
+	c.Assert(a.Intersection(b).Copy(), check.DeepEquals, []int{2, 3})
+	c.Assert(a.Difference(b).Copy(), check.DeepEquals, []int{1, 2, 5})
+	c.Assert(b.Difference(a).Copy(), check.DeepEquals, []int{3, 4})
+	c.Assert(a.SymmetricDifference(b).Copy(), check.DeepEquals, []int{1, 2, 3, 4, 5})
+
+	empty := newIntSortedSlice()
+	c.Assert(a.Union(empty).Copy(), check.DeepEquals, a.Copy())
+	c.Assert(empty.Intersection(a).Len(), check.Equals, 0)
+	c.Assert(empty.Difference(a).Len(), check.Equals, 0)
+
+	u := a.Union(b)
+	u.Add(0)
+	c.Assert(u.At(0), check.Equals, 0)
+	c.Assert(a.Len(), check.Equals, 5)
+}
+
+func (s *sortedSliceSetSuite) TestStreaming(c *check.C) {
+	a := newIntSortedSlice(1, 2, 3)
+	b := newIntSortedSlice(3, 4, 5)
+	var res []int
+	a.UnionFunc(b, func(val int) bool {
+		res = append(res, val)
+		return len(res) < 4
+	})
+	c.Assert(res, check.DeepEquals, []int{1, 2, 3, 4})
+
+	res = nil
+	a.SymmetricDifferenceFunc(b, func(val int) bool {
+		res = append(res, val)
+		return true
+	})
+	c.Assert(res, check.DeepEquals, []int{1, 2, 4, 5})
+
+	res = nil
+	a.IntersectionFunc(b, func(val int) bool {
+		res = append(res, val)
+		return true
+	})
+	c.Assert(res, check.DeepEquals, []int{3})
+
+	res = nil
+	a.DifferenceFunc(b, func(val int) bool {
+		res = append(res, val)
+		return false
+	})
+	c.Assert(res, check.DeepEquals, []int{1})
+}
+
+func (s *sortedSliceSetSuite) TestSubsetAndEqual(c *check.C) {
+	a := newIntSortedSlice(1, 2, 2)
+	c.Assert(a.IsSubset(newIntSortedSlice(1, 2, 2, 3)), check.Equals, true)
+	c.Assert(a.IsSubset(newIntSortedSlice(1, 2, 3)), check.Equals, false)
+	c.Assert(newIntSortedSlice().IsSubset(a), check.Equals, true)
+	c.Assert(a.Equal(newIntSortedSlice(2, 1, 2)), check.Equals, true)
+	c.Assert(a.Equal(newIntSortedSlice(1, 2, 3)), check.Equals, false)
+	c.Assert(a.Equal(newIntSortedSlice(1, 2)), check.Equals, false)
+}
@@ -0,0 +1,120 @@
+package gorivets
+
+import (
+	"math"
+	"strconv"
+)
+
+type (
+	// Number is a constraint for numeric types which quantiles can be
+	// interpolated for
+	Number interface {
+		~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+			~uintptr | ~float32 | ~float64
+	}
+
+	// QuantileMethod defines how a quantile is calculated when it falls
+	// between 2 elements i and j (i < j) of the collection
+	QuantileMethod int
+)
+
+const (
+	// Linear interpolation between i and j
+	QuantileLinear QuantileMethod = iota
+	// The element i
+	QuantileLower
+	// The element j
+	QuantileHigher
+	// The nearest of i and j, i if the quantile is exactly in the middle
+	QuantileNearest
+	// (i + j) / 2
+	QuantileMidpoint
+)
+
+// Rank returns number of elements which are less than val
+func (ss *SortedSlice[T]) Rank(val T) int {
+	return ss.lowerBound(val)
+}
+
+// Select returns k-th smallest element of the collection (k starts from 0)
+func (ss *SortedSlice[T]) Select(k int) (T, bool) {
+	if k < 0 || k >= len(ss.data) {
+		var zero T
+		return zero, false
+	}
+	return ss.data[k], true
+}
+
+// Quantile returns the element nearest to the q-quantile, q should be in
+// [0..1]. See QuantileOf for interpolation of numeric values.
+func (ss *SortedSlice[T]) Quantile(q float64) (T, bool) {
+	i, j, frac, ok := ss.quantilePos(q)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	if frac > 0.5 {
+		return ss.data[j], true
+	}
+	return ss.data[i], true
+}
+
+// Median returns the middle element, the lower one of 2 middle elements for
+// even number of elements. See MedianOf for numeric values.
+func (ss *SortedSlice[T]) Median() (T, bool) {
+	return ss.Quantile(0.5)
+}
+
+// QuantileOf returns the q-quantile of numeric SortedSlice, q should be in
+// [0..1]. The method defines how the value is calculated if the quantile
+// falls between 2 elements.
+func QuantileOf[T Number](ss *SortedSlice[T], q float64, method QuantileMethod) (float64, bool) {
+	i, j, frac, ok := ss.quantilePos(q)
+	if !ok {
+		return 0, false
+	}
+	lo, hi := float64(ss.data[i]), float64(ss.data[j])
+	switch method {
+	case QuantileLinear:
+		return lo + frac*(hi-lo), true
+	case QuantileLower:
+		return lo, true
+	case QuantileHigher:
+		return hi, true
+	case QuantileNearest:
+		if frac > 0.5 {
+			return hi, true
+		}
+		return lo, true
+	case QuantileMidpoint:
+		return (lo + hi) / 2, true
+	}
+	panic("Unknown quantile method=" + strconv.Itoa(int(method)))
+}
+
+// MedianOf returns median of numeric SortedSlice, it is the average of 2
+// middle elements for even number of elements
+func MedianOf[T Number](ss *SortedSlice[T]) (float64, bool) {
+	return QuantileOf(ss, 0.5, QuantileLinear)
+}
+
+// relative precision of the quantile position, see quantilePos
+const cQuantileEps = 1e-12
+
+// quantilePos returns indexes of 2 elements around the q-quantile and the
+// fraction of the distance between them
+func (ss *SortedSlice[T]) quantilePos(q float64) (int, int, float64, bool) {
+	n := len(ss.data)
+	if n == 0 || q < 0 || q > 1 || math.IsNaN(q) {
+		return 0, 0, 0, false
+	}
+	h := q * float64(n-1)
+	// q*(n-1) can be slightly off an integer or a half (0.28*25 is
+	// 7.000000000000001), snap it to avoid picking the wrong element
+	if r := math.Round(2*h) / 2; math.Abs(h-r) <= cQuantileEps*math.Max(1, h) {
+		h = r
+	}
+	i := int(math.Floor(h))
+	j := int(math.Ceil(h))
+	return i, j, h - float64(i), true
+}
@@ -0,0 +1,122 @@
+package gorivets
+
+import (
+	"math"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+type sortedSliceStatsSuite struct {
+}
+
+var _ = check.Suite(&sortedSliceStatsSuite{})
+
+func (s *sortedSliceStatsSuite) TestRankSelect(c *check.C) {
+	ss := newIntSortedSlice(10, 20, 20, 30)
+	c.Assert(ss.Rank(5), check.Equals, 0)
+	c.Assert(ss.Rank(20), check.Equals, 1)
+	c.Assert(ss.Rank(25), check.Equals, 3)
+	c.Assert(ss.Rank(35), check.Equals, 4)
+
+	v, ok := ss.Select(2)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(v, check.Equals, 20)
+	_, ok = ss.Select(4)
+	c.Assert(ok, check.Equals, false)
+	_, ok = ss.Select(-1)
+	c.Assert(ok, check.Equals, false)
+}
+
+func (s *sortedSliceStatsSuite) TestQuantile(c *check.C) {
+	ss := newIntSortedSlice(1, 2, 3, 4)
+	v, ok := ss.Median()
+	c.Assert(ok, check.Equals, true)
+	c.Assert(v, check.Equals, 2)
+	v, _ = ss.Quantile(0)
+	c.Assert(v, check.Equals, 1)
+	v, _ = ss.Quantile(1)
+	c.Assert(v, check.Equals, 4)
+	v, _ = ss.Quantile(0.9)
+	c.Assert(v, check.Equals, 4)
+	_, ok = ss.Quantile(1.1)
+	c.Assert(ok, check.Equals, false)
+	_, ok = newIntSortedSlice().Median()
+	c.Assert(ok, check.Equals, false)
+
+	m, ok := MedianOf(ss)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(m, check.Equals, 2.5)
+
+	quantile := func(q float64, method QuantileMethod) float64 {
+		v, ok := QuantileOf(ss, q, method)
+		c.Assert(ok, check.Equals, true)
+		return v
+	}
+	// position 0.9 * 3 = 2.7
+	c.Assert(math.Abs(quantile(0.9, QuantileLinear)-3.7) < 1e-9, check.Equals, true)
+	c.Assert(quantile(0.9, QuantileLower), check.Equals, 3.0)
+	c.Assert(quantile(0.9, QuantileHigher), check.Equals, 4.0)
+	c.Assert(quantile(0.9, QuantileNearest), check.Equals, 4.0)
+	c.Assert(quantile(0.9, QuantileMidpoint), check.Equals, 3.5)
+	c.Assert(quantile(0.5, QuantileNearest), check.Equals, 2.0)
+	c.Assert(quantile(1, QuantileLinear), check.Equals, 4.0)
+
+	one := newIntSortedSlice(7)
+	m, _ = MedianOf(one)
+	c.Assert(m, check.Equals, 7.0)
+
+	_, ok = QuantileOf(newIntSortedSlice(), 0.5, QuantileLinear)
+	c.Assert(ok, check.Equals, false)
+	c.Assert(CheckPanic(func() { QuantileOf(ss, 0.5, QuantileMethod(10)) }), check.NotNil)
+}
+
+func (s *sortedSliceStatsSuite) TestQuantileRounding(c *check.C) {
+	// the elements are equal to their indexes
+	seq := func(n int) *SortedSlice[int] {
+		ss := newIntSortedSlice()
+		for i := 0; i < n; i++ {
+			ss.Add(i)
+		}
+		return ss
+	}
+	ss26, ss51 := seq(26), seq(51)
+	for _, tc := range []struct {
+		ss                              *SortedSlice[int]
+		q                               float64
+		elem                            int
+		linear, lower, higher, midpoint float64
+	}{
+		// 0.14 * 25 = 3.5
+		{ss26, 0.14, 3, 3.5, 3, 4, 3.5},
+		// 0.28 * 25 = 7
+		{ss26, 0.28, 7, 7, 7, 7, 7},
+		{ss26, 0.56, 14, 14, 14, 14, 14},
+		{ss51, 0.14, 7, 7, 7, 7, 7},
+		{ss51, 0.28, 14, 14, 14, 14, 14},
+		{ss51, 0.56, 28, 28, 28, 28, 28},
+	} {
+		comment := check.Commentf("n=%d q=%v", tc.ss.Len(), tc.q)
+		v, _ := tc.ss.Quantile(tc.q)
+		c.Assert(v, check.Equals, tc.elem, comment)
+		f, _ := QuantileOf(tc.ss, tc.q, QuantileLinear)
+		c.Assert(f, check.Equals, tc.linear, comment)
+		f, _ = QuantileOf(tc.ss, tc.q, QuantileLower)
+		c.Assert(f, check.Equals, tc.lower, comment)
+		f, _ = QuantileOf(tc.ss, tc.q, QuantileHigher)
+		c.Assert(f, check.Equals, tc.higher, comment)
+		f, _ = QuantileOf(tc.ss, tc.q, QuantileNearest)
+		c.Assert(f, check.Equals, float64(tc.elem), comment)
+		f, _ = QuantileOf(tc.ss, tc.q, QuantileMidpoint)
+		c.Assert(f, check.Equals, tc.midpoint, comment)
+	}
+}
+
+func (s *sortedSliceStatsSuite) TestLatencies(c *check.C) {
+	ss, _ := NewSortedSliceOrdered[time.Duration](1)
+	for i := 1; i <= 100; i++ {
+		ss.Add(time.Duration(i) * time.Millisecond)
+	}
+	p99, _ := QuantileOf(ss, 0.99, QuantileLinear)
+	c.Assert(time.Duration(p99), check.Equals, 99010*time.Microsecond)
+}
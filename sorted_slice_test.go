@@ -1,6 +1,9 @@
 package gorivets
 
 import (
+	"cmp"
+	"testing"
+
 	"gopkg.in/check.v1"
 )
 
@@ -155,3 +158,303 @@ func (s *sortedSliceSuite) TestInt(c *check.C) {
 	c.Check(ss.At(0).(Int64), check.Equals, Int64(3))
 	c.Check(ss.At(1).(Int64), check.Equals, Int64(5))
 }
+
+func (s *sortedSliceSuite) TestOrdered(c *check.C) {
+	ss, err := NewSortedSliceOrdered[string](1)
+	c.Assert(err, check.IsNil)
+	ss.Add("b")
+	ss.Add("c")
+	ss.Add("a")
+	c.Assert(ss.Copy(), check.DeepEquals, []string{"a", "b", "c"})
+
+	idx, ok := ss.Find("b")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(idx, check.Equals, 1)
+	c.Assert(ss.Delete("b"), check.Equals, true)
+	c.Assert(ss.DeleteAt(0), check.Equals, "a")
+	c.Assert(ss.At(0), check.Equals, "c")
+}
+
+func (s *sortedSliceSuite) TestFunc(c *check.C) {
+	ss, err := NewSortedSliceFuncAndParams(func(a, b paramType) int {
+		return CompareInt(b.v, a.v)
+	}, paramType{1}, paramType{3}, paramType{2})
+	c.Assert(err, check.IsNil)
+	c.Assert(ss.Copy(), check.DeepEquals, []paramType{{3}, {2}, {1}})
+	c.Assert(ss.GetInsertPos(paramType{0}), check.Equals, 3)
+	c.Assert(ss.GetInsertPos(paramType{4}), check.Equals, 0)
+
+	_, err = NewSortedSliceFunc(func(a, b int) int { return a - b }, 0)
+	c.Assert(err, check.NotNil)
+}
+
+type modeParam struct {
+	k, v int
+}
+
+func compModeParam(a, b modeParam) int {
+	return CompareInt(a.k, b.k)
+}
+
+func (s *sortedSliceSuite) TestMultiset(c *check.C) {
+	ss, _ := NewSortedSliceFunc(compModeParam, 1)
+	c.Assert(ss.Mode(), check.Equals, SortedMultiset)
+	for i, k := range []int{2, 1, 2, 3, 2} {
+		_, err := ss.Add(modeParam{k, i})
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 1}, {2, 0}, {2, 2}, {2, 4}, {3, 3}})
+	c.Assert(ss.Count(modeParam{k: 2}), check.Equals, 3)
+	c.Assert(ss.Count(modeParam{k: 5}), check.Equals, 0)
+
+	idx, ok := ss.FirstIndex(modeParam{k: 2})
+	c.Assert(idx, check.Equals, 1)
+	c.Assert(ok, check.Equals, true)
+	idx, ok = ss.LastIndex(modeParam{k: 2})
+	c.Assert(idx, check.Equals, 3)
+	c.Assert(ok, check.Equals, true)
+	_, ok = ss.LastIndex(modeParam{k: 0})
+	c.Assert(ok, check.Equals, false)
+	_, ok = ss.LastIndex(modeParam{k: 4})
+	c.Assert(ok, check.Equals, false)
+
+	c.Assert(ss.Delete(modeParam{k: 2}), check.Equals, true)
+	c.Assert(ss.At(1), check.Equals, modeParam{2, 2})
+	c.Assert(ss.DeleteAll(modeParam{k: 2}), check.Equals, 2)
+	c.Assert(ss.DeleteAll(modeParam{k: 2}), check.Equals, 0)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 1}, {3, 3}})
+}
+
+func (s *sortedSliceSuite) TestSetModes(c *check.C) {
+	ss, _ := NewSortedSliceWithMode(compModeParam, SortedSetKeep, 1)
+	ss.Add(modeParam{1, 1})
+	idx, err := ss.Add(modeParam{1, 2})
+	c.Assert(err, check.Equals, ErrAlreadyPresent)
+	c.Assert(idx, check.Equals, 0)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 1}})
+
+	ss, _ = NewSortedSliceWithMode(compModeParam, SortedSetReplace, 1)
+	ss.Add(modeParam{2, 1})
+	ss.Add(modeParam{1, 1})
+	idx, err = ss.Add(modeParam{2, 2})
+	c.Assert(err, check.IsNil)
+	c.Assert(idx, check.Equals, 1)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 1}, {2, 2}})
+
+	_, err = NewSortedSliceWithMode(compModeParam, SortedSliceMode(5), 1)
+	c.Assert(err, check.NotNil)
+}
+
+func (s *sortedSliceSuite) TestNavigation(c *check.C) {
+	ss, _ := NewSortedSliceOrdered[int](1)
+	_, ok := ss.First()
+	c.Assert(ok, check.Equals, false)
+	_, ok = ss.Last()
+	c.Assert(ok, check.Equals, false)
+	_, ok = ss.Floor(1)
+	c.Assert(ok, check.Equals, false)
+
+	for _, v := range []int{10, 20, 20, 30} {
+		ss.Add(v)
+	}
+	v, _ := ss.First()
+	c.Assert(v, check.Equals, 10)
+	v, _ = ss.Last()
+	c.Assert(v, check.Equals, 30)
+
+	idx := func(idx int, ok bool) int {
+		c.Assert(ok, check.Equals, idx >= 0)
+		return idx
+	}
+	c.Assert(idx(ss.Floor(20)), check.Equals, 2)
+	c.Assert(idx(ss.Floor(25)), check.Equals, 2)
+	c.Assert(idx(ss.Floor(5)), check.Equals, -1)
+	c.Assert(idx(ss.Ceiling(20)), check.Equals, 1)
+	c.Assert(idx(ss.Ceiling(15)), check.Equals, 1)
+	c.Assert(idx(ss.Ceiling(35)), check.Equals, -1)
+	c.Assert(idx(ss.Lower(20)), check.Equals, 0)
+	c.Assert(idx(ss.Lower(10)), check.Equals, -1)
+	c.Assert(idx(ss.Higher(20)), check.Equals, 3)
+	c.Assert(idx(ss.Higher(30)), check.Equals, -1)
+
+	span := func(start, end int) [2]int {
+		return [2]int{start, end}
+	}
+	c.Assert(span(ss.Range(10, 30, true, true)), check.Equals, [2]int{0, 4})
+	c.Assert(span(ss.Range(10, 30, false, false)), check.Equals, [2]int{1, 3})
+	c.Assert(span(ss.Range(20, 20, true, true)), check.Equals, [2]int{1, 3})
+	c.Assert(span(ss.Range(20, 20, false, true)), check.Equals, [2]int{3, 3})
+	c.Assert(span(ss.Range(30, 10, true, true)), check.Equals, [2]int{3, 3})
+	c.Assert(span(ss.Range(0, 100, false, false)), check.Equals, [2]int{0, 4})
+}
+
+func (s *sortedSliceSuite) TestAddAll(c *check.C) {
+	ss, _ := NewSortedSliceFunc(compModeParam, 1)
+	ss.Add(modeParam{2, 0})
+	ss.Add(modeParam{5, 0})
+	n, err := ss.AddAll(modeParam{4, 1}, modeParam{2, 1}, modeParam{1, 1}, modeParam{2, 2}, modeParam{6, 1})
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 5)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 1}, {2, 0}, {2, 1}, {2, 2}, {4, 1}, {5, 0}, {6, 1}})
+
+	iss, _ := NewSortedSliceByParams(Int64(1))
+	_, err = iss.AddAll(Int64(2), nil)
+	c.Assert(err, check.NotNil)
+	c.Assert(iss.Len(), check.Equals, 1)
+
+	ss, _ = NewSortedSliceWithMode(compModeParam, SortedSetKeep, 1)
+	ss.Add(modeParam{2, 0})
+	n, _ = ss.AddAll(modeParam{3, 1}, modeParam{2, 1}, modeParam{3, 2}, modeParam{1, 1})
+	c.Assert(n, check.Equals, 2)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 1}, {2, 0}, {3, 1}})
+
+	ss, _ = NewSortedSliceWithMode(compModeParam, SortedSetReplace, 1)
+	ss.Add(modeParam{2, 0})
+	n, _ = ss.AddAll(modeParam{3, 1}, modeParam{2, 1}, modeParam{3, 2})
+	c.Assert(n, check.Equals, 1)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{2, 1}, {3, 2}})
+}
+
+func (s *sortedSliceSuite) TestFromSorted(c *check.C) {
+	ss, err := NewSortedSliceFromSorted(cmp.Compare[int], SortedMultiset, []int{1, 2, 2, 3})
+	c.Assert(err, check.IsNil)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{1, 2, 2, 3})
+	ss.Add(0)
+	c.Assert(ss.At(0), check.Equals, 0)
+
+	_, err = NewSortedSliceFromSorted(cmp.Compare[int], SortedSetKeep, []int{1, 2, 2, 3})
+	c.Assert(err, check.NotNil)
+	_, err = NewSortedSliceFromSorted(cmp.Compare[int], SortedMultiset, []int{1, 3, 2})
+	c.Assert(err, check.NotNil)
+	ss, err = NewSortedSliceFromSorted(cmp.Compare[int], SortedMultiset, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(ss.Len(), check.Equals, 0)
+}
+
+func (s *sortedSliceSuite) TestFindBy(c *check.C) {
+	ss, _ := NewSortedSliceFunc(compModeParam, 1)
+	ss.AddAll(modeParam{2, 0}, modeParam{1, 1}, modeParam{2, 2}, modeParam{4, 3})
+	byKey := func(k int, p modeParam) int {
+		return CompareInt(k, p.k)
+	}
+
+	idx, ok := FindBy(ss, 2, byKey)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(idx, check.Equals, 1)
+	idx, ok = FindBy(ss, 3, byKey)
+	c.Assert(ok, check.Equals, false)
+	c.Assert(idx, check.Equals, -4)
+	c.Assert(LowerBoundBy(ss, 2, byKey), check.Equals, 1)
+	c.Assert(UpperBoundBy(ss, 2, byKey), check.Equals, 3)
+	c.Assert(LowerBoundBy(ss, 5, byKey), check.Equals, 4)
+	c.Assert(UpperBoundBy(ss, 0, byKey), check.Equals, 0)
+
+	c.Assert(DeleteBy(ss, 2, byKey), check.Equals, true)
+	c.Assert(DeleteBy(ss, 3, byKey), check.Equals, false)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 1}, {2, 2}, {4, 3}})
+}
+
+func (s *sortedSliceSuite) TestDeleteRange(c *check.C) {
+	ss := newIntSortedSlice(1, 2, 3, 4, 5, 6, 7, 8)
+	c.Assert(ss.DeleteRange(1, 3), check.Equals, 2)
+	c.Assert(ss.DeleteRange(2, 2), check.Equals, 0)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{1, 4, 5, 6, 7, 8})
+	c.Assert(CheckPanic(func() { ss.DeleteRange(5, 7) }), check.NotNil)
+
+	c.Assert(ss.DeleteIf(func(v int) bool { return v%2 == 0 }), check.Equals, 3)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{1, 5, 7})
+
+	ss.AddAll(2, 3, 3, 9)
+	c.Assert(ss.TruncateBefore(3), check.Equals, 2)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{3, 3, 5, 7, 9})
+	c.Assert(ss.TruncateAfter(7), check.Equals, 1)
+	c.Assert(ss.TruncateAfter(7), check.Equals, 0)
+	c.Assert(ss.TruncateBefore(0), check.Equals, 0)
+	c.Assert(ss.Copy(), check.DeepEquals, []int{3, 3, 5, 7})
+	c.Assert(ss.TruncateBefore(10), check.Equals, 4)
+	c.Assert(ss.Len(), check.Equals, 0)
+}
+
+func (s *sortedSliceSuite) TestDeleteReleasesRefs(c *check.C) {
+	ss, _ := NewSortedSliceFunc(func(a, b *int) int { return CompareInt(*a, *b) }, 10)
+	for i := 0; i < 8; i++ {
+		v := i
+		ss.Add(&v)
+	}
+	ss.DeleteAt(0)
+	ss.DeleteAll(ss.At(0))
+	ss.DeleteRange(0, 1)
+	ss.DeleteIf(func(v *int) bool { return *v == 7 })
+	ss.TruncateAfter(ss.At(1))
+	c.Assert(ss.Len(), check.Equals, 2)
+	for _, v := range ss.data[ss.Len():cap(ss.data)] {
+		c.Assert(v == nil, check.Equals, true)
+	}
+}
+
+func (s *sortedSliceSuite) TestFix(c *check.C) {
+	ss, _ := NewSortedSliceFunc(compModeParam, 1)
+	ss.AddAll(modeParam{1, 0}, modeParam{2, 1}, modeParam{3, 2}, modeParam{3, 3}, modeParam{5, 4})
+
+	ss.data[4].k = 3
+	c.Assert(ss.Fix(4), check.Equals, 4)
+	ss.data[4].k = 0
+	c.Assert(ss.Fix(4), check.Equals, 0)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{0, 4}, {1, 0}, {2, 1}, {3, 2}, {3, 3}})
+	ss.data[1].k = 3
+	c.Assert(ss.Fix(1), check.Equals, 4)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{0, 4}, {2, 1}, {3, 2}, {3, 3}, {3, 0}})
+	ss.data[1].k = 4
+	c.Assert(ss.Fix(1), check.Equals, 4)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{0, 4}, {3, 2}, {3, 3}, {3, 0}, {4, 1}})
+
+	idx, err := ss.Update(4, modeParam{1, 5})
+	c.Assert(err, check.IsNil)
+	c.Assert(idx, check.Equals, 1)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{0, 4}, {1, 5}, {3, 2}, {3, 3}, {3, 0}})
+}
+
+func (s *sortedSliceSuite) TestUpdateSetModes(c *check.C) {
+	ss, _ := NewSortedSliceWithMode(compModeParam, SortedSetKeep, 1)
+	ss.AddAll(modeParam{1, 0}, modeParam{2, 1}, modeParam{3, 2})
+	idx, err := ss.Update(0, modeParam{3, 3})
+	c.Assert(err, check.Equals, ErrAlreadyPresent)
+	c.Assert(idx, check.Equals, 2)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 0}, {2, 1}, {3, 2}})
+	idx, err = ss.Update(2, modeParam{3, 4})
+	c.Assert(err, check.IsNil)
+	c.Assert(idx, check.Equals, 2)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 0}, {2, 1}, {3, 4}})
+
+	ss, _ = NewSortedSliceWithMode(compModeParam, SortedSetReplace, 1)
+	ss.AddAll(modeParam{1, 0}, modeParam{2, 1}, modeParam{3, 2})
+	idx, err = ss.Update(2, modeParam{1, 3})
+	c.Assert(err, check.IsNil)
+	c.Assert(idx, check.Equals, 0)
+	c.Assert(ss.Copy(), check.DeepEquals, []modeParam{{1, 3}, {2, 1}})
+
+	ns, _ := NewSortedSlice(1)
+	ns.Add(Int64(1))
+	_, err = ns.Update(0, nil)
+	c.Assert(err, check.NotNil)
+}
+
+func BenchmarkSortedSliceAdd(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ss, _ := NewSortedSliceOrdered[int](1)
+		for v := 0; v < 10000; v++ {
+			ss.Add((v * 7919) % 10000)
+		}
+	}
+}
+
+func BenchmarkSortedSliceAddAll(b *testing.B) {
+	vals := make([]int, 10000)
+	for v := range vals {
+		vals[v] = (v * 7919) % 10000
+	}
+	for i := 0; i < b.N; i++ {
+		ss, _ := NewSortedSliceOrdered[int](1)
+		ss.AddAll(vals...)
+	}
+}
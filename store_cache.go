@@ -0,0 +1,291 @@
+package gorivets
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// Store is a backing storage for StoreCache
+	Store interface {
+		// Loads value and its size by the key. Returns nil value and nil error
+		// if there is no such key in the store
+		Load(k interface{}) (v interface{}, size int64, err error)
+		Save(k, v interface{}) error
+		Delete(k interface{}) error
+	}
+
+	WriteMode int
+
+	StoreCacheConfig struct {
+		// Maximum size of the LRU, see NewLRU
+		MaxSize int64
+		// If positive, time-based LRU is used, see NewTtlLRU
+		Ttl  time.Duration
+		Mode WriteMode
+		// Write-behind mode: how often the dirty entries are flushed, 1 second
+		// if not specified
+		FlushInterval time.Duration
+		// Write-behind mode: number of dirty entries which triggers flush
+		// before FlushInterval expires, never if not positive
+		BatchSize int
+		// Called for errors which happen in background, when a dirty entry is
+		// flushed asynchronously or on eviction. It is called without holding
+		// the cache lock, so it can use the cache.
+		ErrorCallback func(k interface{}, err error)
+	}
+
+	// StoreCache is LRU based cache in front of a Store. In WriteThrough mode
+	// Add() saves the value into the store synchronously. In WriteBehind mode
+	// Add() just marks the entry dirty. The dirty entries are flushed to the
+	// store in batches by a background goroutine, or synchronously when the
+	// LRU evicts them. An evicted entry which could not be saved stays dirty,
+	// and is returned by Get(), till a flush saves it. Store calls are made
+	// under the cache lock, so the store never sees an older value saved
+	// after a newer one. The price is that a slow store call (Load on a cache
+	// miss, or the periodic flush) blocks all other cache operations,
+	// including cache hits, till it is done.
+	//
+	// The cache is goroutine-safe.
+	StoreCache struct {
+		lock   sync.Mutex
+		lru    LRU
+		store  Store
+		cfg    StoreCacheConfig
+		dirty  map[interface{}]interface{}
+		kickCh chan bool
+		stopCh chan bool
+		wg     sync.WaitGroup
+		closed bool
+		// errors to be passed to ErrorCallback after the lock is released
+		errs []storeError
+	}
+
+	storeError struct {
+		k   interface{}
+		err error
+	}
+)
+
+const (
+	WriteThrough WriteMode = iota
+	WriteBehind
+)
+
+func NewStoreCache(store Store, cfg StoreCacheConfig) *StoreCache {
+	if store == nil {
+		panic("Store should be provided.")
+	}
+	sc := &StoreCache{store: store, cfg: cfg}
+	if cfg.Ttl > 0 {
+		sc.lru = NewTtlLRU(cfg.MaxSize, cfg.Ttl, sc.onEvict)
+	} else {
+		sc.lru = NewLRU(cfg.MaxSize, sc.onEvict)
+	}
+
+	switch cfg.Mode {
+	case WriteThrough:
+	case WriteBehind:
+		if sc.cfg.FlushInterval <= 0 {
+			sc.cfg.FlushInterval = time.Second
+		}
+		sc.dirty = make(map[interface{}]interface{})
+		sc.kickCh = make(chan bool, 1)
+		sc.stopCh = make(chan bool)
+		sc.wg.Add(1)
+		go sc.flusher()
+	default:
+		panic("Unknown write mode=" + strconv.Itoa(int(cfg.Mode)))
+	}
+	return sc
+}
+
+// Add puts the value into the cache. In WriteThrough mode the value is saved
+// into the store first, and it is not cached if the store returns an error.
+func (sc *StoreCache) Add(k, v interface{}, size int64) error {
+	sc.lock.Lock()
+	defer sc.unlock()
+
+	if sc.closed {
+		return errors.New("The StoreCache is closed.")
+	}
+
+	if sc.cfg.Mode == WriteThrough {
+		if err := sc.store.Save(k, v); err != nil {
+			return err
+		}
+	}
+
+	// the replaced value must not be flushed by the eviction callback
+	sc.lru.DeleteWithCallback(k, false)
+	if sc.dirty != nil {
+		sc.dirty[k] = v
+	}
+	sc.lru.Add(k, v, size)
+
+	if sc.cfg.BatchSize > 0 && len(sc.dirty) >= sc.cfg.BatchSize {
+		select {
+		case sc.kickCh <- true:
+		default:
+		}
+	}
+	return nil
+}
+
+// Get returns the value from the cache, or loads it from the store if the
+// value is not cached. Returns nil if the key is not found.
+func (sc *StoreCache) Get(k interface{}) (interface{}, error) {
+	sc.lock.Lock()
+	defer sc.unlock()
+
+	if v, ok := sc.lru.Get(k); ok {
+		return v, nil
+	}
+	// evicted entry which could not be saved, the store has an older value
+	if v, ok := sc.dirty[k]; ok {
+		return v, nil
+	}
+
+	v, size, err := sc.store.Load(k)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	sc.lru.Add(k, v, size)
+	return v, nil
+}
+
+// Delete removes the key from the cache and from the store
+func (sc *StoreCache) Delete(k interface{}) error {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.lru.DeleteWithCallback(k, false)
+	if sc.dirty != nil {
+		delete(sc.dirty, k)
+	}
+	return sc.store.Delete(k)
+}
+
+// Flush saves all dirty entries to the store. Returns the first error if any,
+// the entries which could not be saved stay dirty.
+func (sc *StoreCache) Flush() error {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	return sc.flush(nil)
+}
+
+// Close stops the background flushing and flushes dirty entries
+func (sc *StoreCache) Close() error {
+	sc.lock.Lock()
+	if sc.closed {
+		sc.lock.Unlock()
+		return nil
+	}
+	sc.closed = true
+	sc.lock.Unlock()
+
+	if sc.stopCh != nil {
+		close(sc.stopCh)
+		sc.wg.Wait()
+	}
+	return sc.Flush()
+}
+
+func (sc *StoreCache) Len() int {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	return sc.lru.Len()
+}
+
+func (sc *StoreCache) Size() int64 {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	return sc.lru.Size()
+}
+
+// Number of entries which are not saved into the store yet
+func (sc *StoreCache) DirtyLen() int {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	return len(sc.dirty)
+}
+
+// flush saves the dirty entries, every error is passed to onErr if it is not
+// nil. Returns the first error.
+func (sc *StoreCache) flush(onErr func(k interface{}, err error)) error {
+	var res error
+	for k, v := range sc.dirty {
+		if err := sc.store.Save(k, v); err != nil {
+			if res == nil {
+				res = err
+			}
+			if onErr != nil {
+				onErr(k, err)
+			}
+			continue
+		}
+		delete(sc.dirty, k)
+	}
+	return res
+}
+
+// onEvict is called by LRU under the lock
+func (sc *StoreCache) onEvict(k, v interface{}) {
+	if sc.dirty == nil {
+		return
+	}
+	if _, ok := sc.dirty[k]; !ok {
+		return
+	}
+	if err := sc.store.Save(k, v); err != nil {
+		// the entry stays dirty to be saved by the next flush
+		sc.onError(k, err)
+		return
+	}
+	delete(sc.dirty, k)
+}
+
+// onError is called under the lock, the error is passed to ErrorCallback by
+// unlock()
+func (sc *StoreCache) onError(k interface{}, err error) {
+	if sc.cfg.ErrorCallback != nil {
+		sc.errs = append(sc.errs, storeError{k, err})
+	}
+}
+
+// unlock releases the lock and reports the collected errors
+func (sc *StoreCache) unlock() {
+	errs := sc.errs
+	sc.errs = nil
+	sc.lock.Unlock()
+
+	for _, se := range errs {
+		sc.cfg.ErrorCallback(se.k, se.err)
+	}
+}
+
+func (sc *StoreCache) flusher() {
+	defer sc.wg.Done()
+	ticker := time.NewTicker(sc.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sc.stopCh:
+			return
+		case <-ticker.C:
+		case <-sc.kickCh:
+		}
+
+		sc.lock.Lock()
+		sc.lru.Sweep()
+		sc.flush(sc.onError)
+		sc.unlock()
+	}
+}
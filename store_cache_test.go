@@ -0,0 +1,181 @@
+package gorivets
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+type storeCacheSuite struct {
+}
+
+var _ = check.Suite(&storeCacheSuite{})
+
+type memStore struct {
+	lock  sync.Mutex
+	data  map[interface{}]interface{}
+	saves int
+	fail  bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: make(map[interface{}]interface{})}
+}
+
+func (ms *memStore) Load(k interface{}) (interface{}, int64, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	return ms.data[k], 1, nil
+}
+
+func (ms *memStore) Save(k, v interface{}) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	if ms.fail {
+		return errors.New("save failed")
+	}
+	ms.saves++
+	ms.data[k] = v
+	return nil
+}
+
+func (ms *memStore) Delete(k interface{}) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	delete(ms.data, k)
+	return nil
+}
+
+func (ms *memStore) get(k interface{}) (interface{}, bool) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	v, ok := ms.data[k]
+	return v, ok
+}
+
+func (s *storeCacheSuite) TestWriteThrough(c *check.C) {
+	ms := newMemStore()
+	sc := NewStoreCache(ms, StoreCacheConfig{MaxSize: 2})
+	defer sc.Close()
+
+	c.Assert(sc.Add("a", 1, 1), check.IsNil)
+	v, _ := ms.get("a")
+	c.Assert(v, check.Equals, 1)
+
+	ms.data["b"] = 2
+	v, err := sc.Get("b")
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, 2)
+	c.Assert(sc.Len(), check.Equals, 2)
+
+	v, err = sc.Get("c")
+	c.Assert(v, check.IsNil)
+	c.Assert(err, check.IsNil)
+
+	ms.fail = true
+	c.Assert(sc.Add("c", 3, 1), check.NotNil)
+	_, ok := sc.lru.Peek("c")
+	c.Assert(ok, check.Equals, false)
+	ms.fail = false
+
+	c.Assert(sc.Delete("a"), check.IsNil)
+	_, ok = ms.get("a")
+	c.Assert(ok, check.Equals, false)
+	c.Assert(sc.Len(), check.Equals, 1)
+}
+
+func (s *storeCacheSuite) TestWriteBehindEviction(c *check.C) {
+	ms := newMemStore()
+	sc := NewStoreCache(ms, StoreCacheConfig{MaxSize: 2, Mode: WriteBehind, FlushInterval: time.Hour})
+	defer sc.Close()
+
+	sc.Add("a", 1, 1)
+	sc.Add("a", 2, 1)
+	sc.Add("b", 3, 1)
+	c.Assert(ms.saves, check.Equals, 0)
+	c.Assert(sc.DirtyLen(), check.Equals, 2)
+
+	// evicts dirty "a"
+	sc.Add("c", 4, 1)
+	c.Assert(ms.saves, check.Equals, 1)
+	v, _ := ms.get("a")
+	c.Assert(v, check.Equals, 2)
+	c.Assert(sc.DirtyLen(), check.Equals, 2)
+
+	c.Assert(sc.Flush(), check.IsNil)
+	c.Assert(sc.DirtyLen(), check.Equals, 0)
+	c.Assert(ms.saves, check.Equals, 3)
+
+	// clean entry is not saved on eviction
+	sc.Add("d", 5, 1)
+	c.Assert(ms.saves, check.Equals, 3)
+}
+
+func (s *storeCacheSuite) TestWriteBehindBatch(c *check.C) {
+	ms := newMemStore()
+	sc := NewStoreCache(ms, StoreCacheConfig{MaxSize: 100, Mode: WriteBehind, FlushInterval: time.Hour, BatchSize: 3})
+
+	sc.Add("a", 1, 1)
+	sc.Add("b", 2, 1)
+	sc.Add("c", 3, 1)
+	for i := 0; i < 100 && sc.DirtyLen() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(sc.DirtyLen(), check.Equals, 0)
+	c.Assert(len(ms.data), check.Equals, 3)
+
+	sc.Add("d", 4, 1)
+	c.Assert(sc.Close(), check.IsNil)
+	v, _ := ms.get("d")
+	c.Assert(v, check.Equals, 4)
+	c.Assert(sc.Add("e", 5, 1), check.NotNil)
+}
+
+func TestStoreCacheErrorCallback(t *testing.T) {
+	ms := newMemStore()
+	var errKey interface{}
+	var sc *StoreCache
+	errDirty := -1
+	// the callback is called without the lock, so it can use the cache
+	sc = NewStoreCache(ms, StoreCacheConfig{MaxSize: 1, Mode: WriteBehind, FlushInterval: time.Hour,
+		ErrorCallback: func(k interface{}, err error) { errKey, errDirty = k, sc.DirtyLen() }})
+	defer sc.Close()
+
+	ms.fail = true
+	sc.Add("a", 1, 1)
+	if sc.Flush() == nil || sc.DirtyLen() != 1 {
+		t.Fatal("expecting flush error and \"a\" is still dirty")
+	}
+	sc.Add("b", 2, 1)
+	if errKey != "a" || errDirty != 2 {
+		t.Fatal("expecting error callback for \"a\" which stays dirty")
+	}
+	ms.fail = false
+}
+
+func (s *storeCacheSuite) TestWriteBehindEvictionRetry(c *check.C) {
+	ms := newMemStore()
+	sc := NewStoreCache(ms, StoreCacheConfig{MaxSize: 1, Mode: WriteBehind, FlushInterval: time.Hour})
+
+	ms.fail = true
+	sc.Add("a", 1, 1)
+	ms.data["a"] = 0
+	// evicts dirty "a", which cannot be saved
+	sc.Add("b", 2, 1)
+	c.Assert(sc.Len(), check.Equals, 1)
+	c.Assert(sc.DirtyLen(), check.Equals, 2)
+	v, err := sc.Get("a")
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, 1)
+
+	ms.fail = false
+	c.Assert(sc.Close(), check.IsNil)
+	c.Assert(sc.DirtyLen(), check.Equals, 0)
+	v, _ = ms.get("a")
+	c.Assert(v, check.Equals, 1)
+	v, _ = ms.get("b")
+	c.Assert(v, check.Equals, 2)
+}